@@ -0,0 +1,549 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxBatchWriteItems is the maximum number of items DynamoDB accepts in one BatchWriteItem request.
+const maxBatchWriteItems = 25
+
+// ErrUnprocessed is reported for batch items DynamoDB left unprocessed after every retry.
+var ErrUnprocessed = errors.New("dynamo: item not processed")
+
+// BatchOption customizes a batch operation such as BatchSave or BatchDelete.
+type BatchOption func(o *batchOptions)
+
+type batchOptions struct {
+	workers int
+	retries int
+	backoff time.Duration
+}
+
+// WithWorkers sets how many requests of a batch run concurrently. Defaults to 4.
+func WithWorkers(workers int) BatchOption {
+	return func(o *batchOptions) {
+		o.workers = workers
+	}
+}
+
+// WithRetries sets how many times unprocessed items are sent again. Defaults to 5.
+func WithRetries(retries int) BatchOption {
+	return func(o *batchOptions) {
+		o.retries = retries
+	}
+}
+
+// WithBackoff sets the wait before the first retry of unprocessed items. It doubles
+// on every following retry. Defaults to 50ms.
+func WithBackoff(backoff time.Duration) BatchOption {
+	return func(o *batchOptions) {
+		o.backoff = backoff
+	}
+}
+
+func newBatchOptions(opts []BatchOption) *batchOptions {
+	o := &batchOptions{
+		workers: 4,
+		retries: 5,
+		backoff: 50 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.workers < 1 {
+		o.workers = 1
+	}
+	return o
+}
+
+// BatchError reports the items of a batch that could not be written. The other
+// items were written.
+type BatchError struct {
+	Failures []BatchFailure
+}
+
+// BatchFailure is an item of a batch that could not be written.
+type BatchFailure struct {
+	// Index is the position of the item in the slice passed to the batch operation.
+	Index int
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("dynamo: %d batch items failed, first at index %d: %v", len(e.Failures), e.Failures[0].Index, e.Failures[0].Err)
+}
+
+// Unwrap exposes the error of every failed item to errors.Is and errors.As.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, failure := range e.Failures {
+		errs = append(errs, failure.Err)
+	}
+	return errs
+}
+
+// batchRequest is a write request together with the position of its item in the input.
+type batchRequest struct {
+	index   int
+	key     string
+	request types.WriteRequest
+}
+
+// BatchSave puts every item of the items slice with BatchWriteItem. Items are sent in
+// requests of 25 and unprocessed items are retried with backoff. Conditions and
+// version fields are not checked, and the created timestamp of a stored item is
+// replaced, as BatchWriteItem does not support conditions. An item
+// whose key repeats the key of an earlier item fails with ErrInvalidKey, as DynamoDB
+// rejects requests that write one key twice. When some items fail the returned error
+// is a *BatchError.
+func (i *Implementation) BatchSave(ctx context.Context, table string, items interface{}, opts ...BatchOption) error {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return errors.New("dynamo: BatchSave expects a slice of items")
+	}
+	def, err := i.table(table)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DynamoDB] executing batch save of %d items", v.Len())
+
+	var failures []BatchFailure
+	requests := make([]batchRequest, 0, v.Len())
+	for idx := 0; idx < v.Len(); idx++ {
+		item, err := attributevalue.MarshalMapWithOptions(v.Index(idx).Interface(), func(h *attributevalue.EncoderOptions) {
+			h.TagKey = Tagkey
+		})
+		if err != nil {
+			failures = append(failures, BatchFailure{Index: idx, Err: fmt.Errorf("failed to DynamoDB marshal Record, %w", err)})
+			continue
+		}
+		now := i.now()
+		def.stampExpiry(item, &writeOptions{}, now)
+		def.stampTimestamps(item, now)
+		requests = append(requests, batchRequest{
+			index:   idx,
+			key:     def.keyString(item),
+			request: types.WriteRequest{PutRequest: &types.PutRequest{Item: item}},
+		})
+	}
+	return i.batchWrite(ctx, def, requests, failures, newBatchOptions(opts))
+}
+
+// BatchDelete deletes the items with the given keys with BatchWriteItem, chunking and
+// retrying as BatchSave does.
+func (i *Implementation) BatchDelete(ctx context.Context, table string, keys []Key, opts ...BatchOption) error {
+	def, err := i.table(table)
+	if err != nil {
+		return err
+	}
+	log.Printf("[DynamoDB] executing batch delete of %d items", len(keys))
+
+	var failures []BatchFailure
+	requests := make([]batchRequest, 0, len(keys))
+	for idx, key := range keys {
+		itemKey, err := def.itemKey(key)
+		if err != nil {
+			failures = append(failures, BatchFailure{Index: idx, Err: err})
+			continue
+		}
+		requests = append(requests, batchRequest{
+			index:   idx,
+			key:     def.keyString(itemKey),
+			request: types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: itemKey}},
+		})
+	}
+	return i.batchWrite(ctx, def, requests, failures, newBatchOptions(opts))
+}
+
+// batchWrite sends the requests in chunks of maxBatchWriteItems using ops.workers
+// concurrent workers, and collects the items that failed. Requests repeating the key
+// of an earlier request fail without being sent.
+func (i *Implementation) batchWrite(ctx context.Context, def DynamoTable, requests []batchRequest, failures []BatchFailure, ops *batchOptions) error {
+	requests, failures = uniqueRequests(requests, failures)
+	chunks := make(chan []batchRequest)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < ops.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				chunkFailures := i.writeChunk(ctx, def, chunk, ops)
+				if len(chunkFailures) == 0 {
+					continue
+				}
+				mu.Lock()
+				failures = append(failures, chunkFailures...)
+				mu.Unlock()
+			}
+		}()
+	}
+	for start := 0; start < len(requests); start += maxBatchWriteItems {
+		chunks <- requests[start:min(start+maxBatchWriteItems, len(requests))]
+	}
+	close(chunks)
+	wg.Wait()
+
+	if len(failures) == 0 {
+		return nil
+	}
+	sort.Slice(failures, func(a, b int) bool {
+		return failures[a].Index < failures[b].Index
+	})
+	return &BatchError{Failures: failures}
+}
+
+// uniqueRequests drops the requests whose key was already used by an earlier request,
+// reporting them as failures.
+func uniqueRequests(requests []batchRequest, failures []BatchFailure) ([]batchRequest, []BatchFailure) {
+	first := make(map[string]int, len(requests))
+	unique := requests[:0:0]
+	for _, request := range requests {
+		if index, ok := first[request.key]; ok {
+			failures = append(failures, BatchFailure{
+				Index: request.index,
+				Err:   fmt.Errorf("%w: item repeats the key of the item at index %d", ErrInvalidKey, index),
+			})
+			continue
+		}
+		first[request.key] = request.index
+		unique = append(unique, request)
+	}
+	return unique, failures
+}
+
+// writeChunk writes one chunk, sending its unprocessed items again until they are
+// written or the retries run out.
+func (i *Implementation) writeChunk(ctx context.Context, def DynamoTable, chunk []batchRequest, ops *batchOptions) []BatchFailure {
+	pending := chunk
+	for attempt := 0; ; attempt++ {
+		writes := make([]types.WriteRequest, len(pending))
+		for n, r := range pending {
+			writes[n] = r.request
+		}
+		out, err := i.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{def.TableName: writes},
+		})
+		if err != nil {
+			return batchFailures(pending, fmt.Errorf("failed to batch write items: %w", err))
+		}
+		unprocessed := out.UnprocessedItems[def.TableName]
+		if len(unprocessed) == 0 {
+			return nil
+		}
+		pending = unprocessedRequests(def, pending, unprocessed)
+		if attempt >= ops.retries {
+			return batchFailures(pending, ErrUnprocessed)
+		}
+		log.Printf("[DynamoDB] retrying %d unprocessed batch items", len(pending))
+		if err := sleepContext(ctx, ops.backoff<<attempt); err != nil {
+			return batchFailures(pending, err)
+		}
+	}
+}
+
+// unprocessedRequests returns the pending requests DynamoDB reported as unprocessed,
+// matched by item key so they keep their position in the input.
+func unprocessedRequests(def DynamoTable, pending []batchRequest, unprocessed []types.WriteRequest) []batchRequest {
+	keys := make(map[string]bool, len(unprocessed))
+	for _, request := range unprocessed {
+		switch {
+		case request.PutRequest != nil:
+			keys[def.keyString(request.PutRequest.Item)] = true
+		case request.DeleteRequest != nil:
+			keys[def.keyString(request.DeleteRequest.Key)] = true
+		}
+	}
+	var left []batchRequest
+	for _, request := range pending {
+		if keys[request.key] {
+			left = append(left, request)
+		}
+	}
+	return left
+}
+
+func batchFailures(requests []batchRequest, err error) []BatchFailure {
+	failures := make([]BatchFailure, len(requests))
+	for n, request := range requests {
+		failures[n] = BatchFailure{Index: request.index, Err: err}
+	}
+	return failures
+}
+
+// keyString identifies an item by the values of its key attributes.
+func (d DynamoTable) keyString(item map[string]types.AttributeValue) string {
+	key := attributeString(item[d.PartitionKeyField])
+	if d.SortKeyField != "" {
+		key += "\x00" + attributeString(item[d.SortKeyField])
+	}
+	return key
+}
+
+func attributeString(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return "S" + v.Value
+	case *types.AttributeValueMemberN:
+		return "N" + v.Value
+	case *types.AttributeValueMemberB:
+		return "B" + string(v.Value)
+	}
+	return ""
+}
+
+// sleepContext waits for the given duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// maxBatchGetKeys is the maximum number of keys DynamoDB accepts in one BatchGetItem request.
+const maxBatchGetKeys = 100
+
+// BatchGetRequest lists, per table registered in the client, the keys to read and
+// where to bind the items found.
+type BatchGetRequest map[string]BatchGet
+
+// BatchGet holds the keys to read from one table. BindTo must point to a slice. Items
+// are bound in no particular order, keys without an item are skipped and repeated keys
+// are read once.
+type BatchGet struct {
+	Keys   []Key
+	BindTo interface{}
+}
+
+// BatchGetWithSort reads every key of the request with BatchGetItem and binds the items
+// of each table to its BindTo. Keys include the sort key on tables that define one.
+//
+//	err := client.BatchGetWithSort(dynamodb.BatchGetRequest{
+//		"users":  {Keys: []dynamodb.Key{{PartitionKey: "1"}, {PartitionKey: "2"}}, BindTo: &users},
+//		"orders": {Keys: []dynamodb.Key{{PartitionKey: "1", SortKey: "o-1"}}, BindTo: &orders},
+//	})
+func (i *Implementation) BatchGetWithSort(request BatchGetRequest, opts ...BatchOption) error {
+	return i.BatchGetWithSortWithContext(context.TODO(), request, opts...)
+}
+
+// BatchGetWithSortWithContext is BatchGetWithSort with a caller supplied context.
+func (i *Implementation) BatchGetWithSortWithContext(ctx context.Context, request BatchGetRequest, opts ...BatchOption) error {
+	log.Printf("[DynamoDB] executing BatchGet query")
+	keys := make(map[string]types.KeysAndAttributes, len(request))
+	bindTo := make(map[string]interface{}, len(request))
+	for table, get := range request {
+		def, err := i.table(table)
+		if err != nil {
+			return err
+		}
+		var tableKeys []map[string]types.AttributeValue
+		for _, key := range get.Keys {
+			itemKey, err := def.itemKey(key)
+			if err != nil {
+				return err
+			}
+			tableKeys = append(tableKeys, itemKey)
+		}
+		keys[def.TableName] = types.KeysAndAttributes{Keys: tableKeys}
+		bindTo[def.TableName] = get.BindTo
+	}
+	return i.batchGetItems(ctx, keys, bindTo, opts...)
+}
+
+// BatchGetItem reads the keys with a single BatchGetItem request. values holds, per
+// table, the positional []interface{}{partitionKey, sortKey, bindTo} of the original
+// API.
+//
+// Deprecated: BatchGetItem neither retries unprocessed keys nor fills bindTo. Use
+// BatchGetWithSort, which binds the items of each table to its BindTo.
+func (i *Implementation) BatchGetItem(key map[string]types.KeysAndAttributes, values map[string]interface{}) error {
+	return i.BatchGetItemWithContext(context.TODO(), key, values)
+}
+
+// BatchGetItemWithContext is BatchGetItem with a caller supplied context.
+//
+// Deprecated: use BatchGetWithSortWithContext.
+func (i *Implementation) BatchGetItemWithContext(ctx context.Context, key map[string]types.KeysAndAttributes, values map[string]interface{}) error {
+	log.Printf("[DynamoDB] batch get query")
+	out, err := i.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+		RequestItems: key,
+	})
+	if err != nil {
+		return err
+	}
+	var bindList []interface{}
+	for i, o := range out.Responses {
+		for t, v := range values {
+			if i == t {
+				v, _ := v.([]interface{})
+				bindList := append(bindList, v[2])
+				err = attributevalue.UnmarshalListOfMapsWithOptions(o, &bindList, func(options *attributevalue.DecoderOptions) {
+					options.TagKey = Tagkey
+				})
+			}
+		}
+	}
+	return err
+}
+
+// batchGetItems reads the keys with BatchGetItem and binds the items of each table to
+// bindTo[table], which must point to a slice. Repeated keys are read once. Requests
+// are split in chunks of 100 keys and unprocessed keys are retried with backoff.
+func (i *Implementation) batchGetItems(ctx context.Context, key map[string]types.KeysAndAttributes, bindTo map[string]interface{}, opts ...BatchOption) error {
+	ops := newBatchOptions(opts)
+
+	responses := make(map[string][]map[string]types.AttributeValue)
+	chunks := make(chan map[string]types.KeysAndAttributes)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	for w := 0; w < ops.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for chunk := range chunks {
+				items, err := i.getChunk(ctx, chunk, ops)
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				for table, found := range items {
+					responses[table] = append(responses[table], found...)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, chunk := range batchGetChunks(key) {
+		chunks <- chunk
+	}
+	close(chunks)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	for table, bind := range bindTo {
+		items := responses[table]
+		if def, err := i.table(table); err == nil {
+			items = def.live(items, i.now())
+		}
+		err := attributevalue.UnmarshalListOfMapsWithOptions(items, bind, func(options *attributevalue.DecoderOptions) {
+			options.TagKey = Tagkey
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchGetChunks splits the keys of every table in requests of at most maxBatchGetKeys
+// keys, keeping the projection and consistency settings of each table. Repeated keys
+// are dropped, as DynamoDB rejects a request that names one key twice.
+func batchGetChunks(key map[string]types.KeysAndAttributes) []map[string]types.KeysAndAttributes {
+	tables := make([]string, 0, len(key))
+	for table := range key {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var chunks []map[string]types.KeysAndAttributes
+	chunk := map[string]types.KeysAndAttributes{}
+	size := 0
+	for _, table := range tables {
+		keys := uniqueKeys(key[table].Keys)
+		for len(keys) > 0 {
+			n := min(maxBatchGetKeys-size, len(keys))
+			part := key[table]
+			part.Keys = append(chunk[table].Keys, keys[:n]...)
+			chunk[table] = part
+			keys = keys[n:]
+			size += n
+			if size == maxBatchGetKeys {
+				chunks = append(chunks, chunk)
+				chunk = map[string]types.KeysAndAttributes{}
+				size = 0
+			}
+		}
+	}
+	if size > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// uniqueKeys returns the keys without the ones repeating an earlier key.
+func uniqueKeys(keys []map[string]types.AttributeValue) []map[string]types.AttributeValue {
+	seen := make(map[string]bool, len(keys))
+	unique := make([]map[string]types.AttributeValue, 0, len(keys))
+	for _, key := range keys {
+		names := make([]string, 0, len(key))
+		for name := range key {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		id := ""
+		for _, name := range names {
+			id += name + "\x00" + attributeString(key[name]) + "\x00"
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, key)
+	}
+	return unique
+}
+
+// getChunk reads one chunk of keys, requesting its unprocessed keys again until they
+// are read or the retries run out.
+func (i *Implementation) getChunk(ctx context.Context, chunk map[string]types.KeysAndAttributes, ops *batchOptions) (map[string][]map[string]types.AttributeValue, error) {
+	items := make(map[string][]map[string]types.AttributeValue)
+	pending := chunk
+	for attempt := 0; ; attempt++ {
+		out, err := i.client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+			RequestItems: pending,
+		})
+		if err != nil {
+			return items, fmt.Errorf("failed to batch get items: %w", err)
+		}
+		for table, found := range out.Responses {
+			items[table] = append(items[table], found...)
+		}
+		if len(out.UnprocessedKeys) == 0 {
+			return items, nil
+		}
+		pending = out.UnprocessedKeys
+		if attempt >= ops.retries {
+			return items, fmt.Errorf("%w: %d keys", ErrUnprocessed, countKeys(pending))
+		}
+		log.Printf("[DynamoDB] retrying %d unprocessed batch keys", countKeys(pending))
+		if err := sleepContext(ctx, ops.backoff<<attempt); err != nil {
+			return items, err
+		}
+	}
+}
+
+func countKeys(keys map[string]types.KeysAndAttributes) int {
+	count := 0
+	for _, k := range keys {
+		count += len(k.Keys)
+	}
+	return count
+}
@@ -0,0 +1,228 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchSaveChunksAndRetries(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	retried := false
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"accounts": {TableName: "accounts", PartitionKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcBatchWriteItem: func(ctx context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				writes := input.RequestItems["accounts"]
+				sizes = append(sizes, len(writes))
+				// leave item 30 unprocessed once
+				for _, w := range writes {
+					if w.PutRequest.Item["id"].(*types.AttributeValueMemberS).Value == "30" && !retried {
+						retried = true
+						return &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{"accounts": {w}}}, nil
+					}
+				}
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			},
+		},
+	}
+
+	var accounts []account
+	for n := 0; n < 60; n++ {
+		accounts = append(accounts, account{ID: strconv.Itoa(n), Balance: n})
+	}
+	err := client.BatchSave(context.Background(), "accounts", accounts, WithWorkers(2), WithBackoff(time.Millisecond))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{25, 25, 10, 1}, sizes)
+}
+
+func TestBatchDeleteReportsFailures(t *testing.T) {
+	calls := 0
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"orders": {TableName: "orders", PartitionKeyField: "user", SortKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcBatchWriteItem: func(ctx context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+				calls++
+				writes := input.RequestItems["orders"]
+				assert.Equal(t, &types.AttributeValueMemberS{Value: "o-2"}, writes[len(writes)-1].DeleteRequest.Key["id"])
+				return &dynamodb.BatchWriteItemOutput{UnprocessedItems: map[string][]types.WriteRequest{"orders": {writes[len(writes)-1]}}}, nil
+			},
+		},
+	}
+
+	err := client.BatchDelete(context.Background(), "orders", []Key{{PartitionKey: "1", SortKey: "o-1"}, {PartitionKey: "1", SortKey: "o-2"}},
+		WithRetries(2), WithBackoff(time.Millisecond))
+	var batchErr *BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, []BatchFailure{{Index: 1, Err: ErrUnprocessed}}, batchErr.Failures)
+	assert.ErrorIs(t, err, ErrUnprocessed)
+	assert.Equal(t, 3, calls)
+}
+
+func TestBatchSaveRequestError(t *testing.T) {
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"accounts": {TableName: "accounts", PartitionKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcBatchWriteItem: func(ctx context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+				return nil, &types.ProvisionedThroughputExceededException{Message: aws.String("slow down")}
+			},
+		},
+	}
+
+	err := client.BatchSave(context.Background(), "accounts", []account{{ID: "1"}, {ID: "2"}})
+	var batchErr *BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Failures, 2)
+	var throughputErr *types.ProvisionedThroughputExceededException
+	assert.True(t, errors.As(err, &throughputErr))
+
+	assert.Error(t, client.BatchSave(context.Background(), "accounts", account{ID: "1"}))
+}
+
+func TestBatchSaveRejectsDuplicateKeys(t *testing.T) {
+	var written []string
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"accounts": {TableName: "accounts", PartitionKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcBatchWriteItem: func(ctx context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+				for _, w := range input.RequestItems["accounts"] {
+					written = append(written, w.PutRequest.Item["id"].(*types.AttributeValueMemberS).Value)
+				}
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			},
+		},
+	}
+
+	err := client.BatchSave(context.Background(), "accounts", []account{{ID: "1"}, {ID: "2"}, {ID: "1", Balance: 5}})
+	var batchErr *BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Len(t, batchErr.Failures, 1)
+	assert.Equal(t, 2, batchErr.Failures[0].Index)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	assert.EqualError(t, batchErr.Failures[0].Err, "dynamo: invalid key: item repeats the key of the item at index 0")
+	assert.Equal(t, []string{"1", "2"}, written)
+}
+
+func TestBatchGetWithSortChunksAndRetries(t *testing.T) {
+	var mu sync.Mutex
+	var sizes []int
+	retried := false
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{
+			"accounts": {TableName: "accounts", PartitionKeyField: "id"},
+			"orders":   {TableName: "orders", PartitionKeyField: "user", SortKeyField: "order_id"},
+		},
+		client: &dynamoClientMock{
+			funcBatchGetItem: func(ctx context.Context, input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				size := 0
+				out := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{}}
+				for table, keys := range input.RequestItems {
+					size += len(keys.Keys)
+					for _, key := range keys.Keys {
+						if table == "accounts" && key["id"].(*types.AttributeValueMemberS).Value == "0" && !retried {
+							retried = true
+							out.UnprocessedKeys = map[string]types.KeysAndAttributes{"accounts": {Keys: []map[string]types.AttributeValue{key}}}
+							continue
+						}
+						if table == "orders" {
+							out.Responses[table] = append(out.Responses[table], orderItem(key["order_id"].(*types.AttributeValueMemberS).Value, "paid"))
+							continue
+						}
+						out.Responses[table] = append(out.Responses[table], key)
+					}
+				}
+				sizes = append(sizes, size)
+				return out, nil
+			},
+		},
+	}
+
+	var keys []Key
+	for n := 0; n < 150; n++ {
+		keys = append(keys, Key{PartitionKey: strconv.Itoa(n)})
+	}
+	var accounts []account
+	var orders []order
+	err := client.BatchGetWithSort(BatchGetRequest{
+		"accounts": {Keys: keys, BindTo: &accounts},
+		"orders":   {Keys: []Key{{PartitionKey: "1", SortKey: "o-1"}}, BindTo: &orders},
+	}, WithBackoff(time.Millisecond))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []int{100, 51, 1}, sizes)
+	assert.Len(t, accounts, 150)
+	assert.Equal(t, []order{{ID: "o-1", Status: "paid", Total: 10}}, orders)
+}
+
+func TestBatchGetWithSortReadsRepeatedKeysOnce(t *testing.T) {
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"orders": {TableName: "orders", PartitionKeyField: "user", SortKeyField: "order_id"}},
+		client: &dynamoClientMock{
+			funcBatchGetItem: func(ctx context.Context, input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+				keys := input.RequestItems["orders"].Keys
+				assert.Len(t, keys, 2)
+				out := &dynamodb.BatchGetItemOutput{Responses: map[string][]map[string]types.AttributeValue{}}
+				for _, key := range keys {
+					out.Responses["orders"] = append(out.Responses["orders"], orderItem(key["order_id"].(*types.AttributeValueMemberS).Value, "paid"))
+				}
+				return out, nil
+			},
+		},
+	}
+
+	var orders []order
+	err := client.BatchGetWithSort(BatchGetRequest{"orders": {
+		Keys:   []Key{{PartitionKey: "1", SortKey: "o-1"}, {PartitionKey: "1", SortKey: "o-2"}, {PartitionKey: "1", SortKey: "o-1"}},
+		BindTo: &orders,
+	}})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 2)
+}
+
+func TestBatchGetWithSortUnprocessed(t *testing.T) {
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"accounts": {TableName: "accounts", PartitionKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcBatchGetItem: func(ctx context.Context, input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error) {
+				return &dynamodb.BatchGetItemOutput{UnprocessedKeys: input.RequestItems}, nil
+			},
+		},
+	}
+
+	var accounts []account
+	err := client.BatchGetWithSort(BatchGetRequest{"accounts": {Keys: []Key{{PartitionKey: "1"}}, BindTo: &accounts}},
+		WithRetries(1), WithBackoff(time.Millisecond))
+	assert.ErrorIs(t, err, ErrUnprocessed)
+}
+
+func TestDynamoLocalDevelopmentBatchGetWithSort(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+			SortKeyField:      "name",
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	var persons []person
+	err := client.BatchGetWithSort(BatchGetRequest{"person": {
+		Keys:   []Key{{PartitionKey: "3", SortKey: "Janet"}, {PartitionKey: "1", SortKey: "John"}, {PartitionKey: "2", SortKey: "John"}},
+		BindTo: &persons,
+	}})
+	assert.NoError(t, err)
+	assert.Len(t, persons, 2)
+	assert.Equal(t, "Janet", persons[0].Name)
+	assert.Equal(t, "John", persons[1].Name)
+}
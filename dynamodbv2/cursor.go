@@ -0,0 +1,144 @@
+package dynamodb
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed or was tampered with.
+var ErrInvalidCursor = errors.New("dynamo: invalid cursor")
+
+// cursorValue is the JSON form of a key attribute. Key attributes are always strings,
+// numbers or binary.
+type cursorValue struct {
+	S *string `json:"s,omitempty"`
+	N *string `json:"n,omitempty"`
+	B []byte  `json:"b,omitempty"`
+}
+
+// WithCursorSigning signs pagination cursors with HMAC-SHA256, so clients can read
+// but not forge them.
+func WithCursorSigning(secret []byte) funcTable {
+	return func(i *Implementation) {
+		i.cursorSecret = secret
+	}
+}
+
+// WithCursorEncryption encrypts pagination cursors with AES-GCM, hiding the keys they
+// hold from clients. The key must be 16, 24 or 32 bytes long.
+func WithCursorEncryption(key []byte) funcTable {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(fmt.Sprintf("dynamo: invalid cursor encryption key: %v", err))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Sprintf("dynamo: invalid cursor encryption key: %v", err))
+	}
+	return func(i *Implementation) {
+		i.cursorAEAD = aead
+	}
+}
+
+// encodeCursor turns a LastEvaluatedKey into an opaque, URL-safe cursor. A nil key,
+// meaning there are no more items, gives an empty cursor.
+func (i *Implementation) encodeCursor(key map[string]types.AttributeValue) (string, error) {
+	if key == nil {
+		return "", nil
+	}
+	values := make(map[string]cursorValue, len(key))
+	for name, av := range key {
+		switch v := av.(type) {
+		case *types.AttributeValueMemberS:
+			values[name] = cursorValue{S: &v.Value}
+		case *types.AttributeValueMemberN:
+			values[name] = cursorValue{N: &v.Value}
+		case *types.AttributeValueMemberB:
+			values[name] = cursorValue{B: v.Value}
+		default:
+			return "", fmt.Errorf("dynamo: unsupported key attribute %s in cursor", name)
+		}
+	}
+	payload, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case i.cursorAEAD != nil:
+		nonce := make([]byte, i.cursorAEAD.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		payload = i.cursorAEAD.Seal(nonce, nonce, payload, nil)
+	case i.cursorSecret != nil:
+		payload = append(payload, i.cursorSignature(payload)...)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+// decodeCursor returns the key encoded in the cursor, or nil for an empty cursor.
+func (i *Implementation) decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	switch {
+	case i.cursorAEAD != nil:
+		nonceSize := i.cursorAEAD.NonceSize()
+		if len(payload) < nonceSize {
+			return nil, ErrInvalidCursor
+		}
+		payload, err = i.cursorAEAD.Open(nil, payload[:nonceSize], payload[nonceSize:], nil)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+	case i.cursorSecret != nil:
+		if len(payload) < sha256.Size {
+			return nil, ErrInvalidCursor
+		}
+		signature := payload[len(payload)-sha256.Size:]
+		payload = payload[:len(payload)-sha256.Size]
+		if !hmac.Equal(signature, i.cursorSignature(payload)) {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	var values map[string]cursorValue
+	if err := json.Unmarshal(payload, &values); err != nil || len(values) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	key := make(map[string]types.AttributeValue, len(values))
+	for name, v := range values {
+		switch {
+		case v.S != nil:
+			key[name] = &types.AttributeValueMemberS{Value: *v.S}
+		case v.N != nil:
+			key[name] = &types.AttributeValueMemberN{Value: *v.N}
+		case v.B != nil:
+			key[name] = &types.AttributeValueMemberB{Value: v.B}
+		default:
+			return nil, ErrInvalidCursor
+		}
+	}
+	return key, nil
+}
+
+func (i *Implementation) cursorSignature(payload []byte) []byte {
+	mac := hmac.New(sha256.New, i.cursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,120 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := map[string]types.AttributeValue{
+		"user":     &types.AttributeValueMemberS{Value: "1"},
+		"order_id": &types.AttributeValueMemberN{Value: "42"},
+		"raw":      &types.AttributeValueMemberB{Value: []byte{0, 1, 2}},
+	}
+	clients := map[string]*Implementation{
+		"plain":     NewImplementation(aws.Config{}),
+		"signed":    NewImplementation(aws.Config{}, WithCursorSigning([]byte("secret"))),
+		"encrypted": NewImplementation(aws.Config{}, WithCursorEncryption([]byte("0123456789abcdef"))),
+	}
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			cursor, err := client.encodeCursor(key)
+			assert.NoError(t, err)
+			assert.NotContains(t, cursor, "=")
+
+			decoded, err := client.decodeCursor(cursor)
+			assert.NoError(t, err)
+			assert.Equal(t, key, decoded)
+
+			tampered := []byte(cursor)
+			tampered[len(tampered)/2] ^= 1
+			_, err = client.decodeCursor(string(tampered))
+			if name != "plain" {
+				assert.ErrorIs(t, err, ErrInvalidCursor)
+			}
+		})
+	}
+
+	empty, err := clients["plain"].encodeCursor(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+
+	signed, _ := clients["signed"].encodeCursor(key)
+	_, err = clients["encrypted"].decodeCursor(signed)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = clients["plain"].decodeCursor("not a cursor!")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	assert.Panics(t, func() { WithCursorEncryption([]byte("short")) })
+}
+
+func TestQueryExpressionPage(t *testing.T) {
+	var inputs []dynamodb.QueryInput
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"orders": {
+			TableName: "orders", PartitionKeyField: "user", SortKeyField: "order_id", MaxPageSize: 3,
+			GlobalIndexes: []Index{{Name: "by-status", PartitionKeyField: "status"}},
+		}},
+		client: &dynamoClientMock{
+			funcQuery: func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+				inputs = append(inputs, *input)
+				// the filter drops one item of every full response
+				switch len(inputs) {
+				case 1:
+					return &dynamodb.QueryOutput{
+						Items:            []map[string]types.AttributeValue{orderItem("1", "new"), orderItem("2", "new")},
+						Count:            2,
+						ScannedCount:     3,
+						ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)},
+						LastEvaluatedKey: map[string]types.AttributeValue{"user": &types.AttributeValueMemberS{Value: "u"}, "order_id": &types.AttributeValueMemberS{Value: "3"}},
+					}, nil
+				case 2:
+					return &dynamodb.QueryOutput{
+						Items:            []map[string]types.AttributeValue{orderItem("4", "new")},
+						Count:            1,
+						ScannedCount:     1,
+						ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)},
+						LastEvaluatedKey: map[string]types.AttributeValue{"user": &types.AttributeValueMemberS{Value: "u"}, "order_id": &types.AttributeValueMemberS{Value: "4"}},
+					}, nil
+				}
+				return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{orderItem("5", "new")}, Count: 1}, nil
+			},
+		},
+	}
+
+	keyCond := expression.Key("user").Equal(expression.Value("u"))
+	query, err := expression.NewBuilder().WithKeyCondition(keyCond).Build()
+	assert.NoError(t, err)
+
+	var page []order
+	result, err := client.QueryExpressionPage("orders", query, 0, "", &page)
+	assert.NoError(t, err)
+	assert.Len(t, page, 3)
+	assert.True(t, result.HasMore)
+	assert.NotEmpty(t, result.Cursor)
+	assert.Equal(t, int32(3), result.Count)
+	assert.Equal(t, int32(4), result.ScannedCount)
+	assert.Equal(t, 1.0, result.ConsumedCapacity)
+	assert.Equal(t, 2, result.Pages)
+	assert.Nil(t, inputs[0].ExclusiveStartKey)
+	assert.Equal(t, int32(3), *inputs[0].Limit)
+	assert.Equal(t, int32(1), *inputs[1].Limit)
+
+	var last []order
+	result, err = client.QueryGSIPage("orders", "by-status", query, 5, result.Cursor, &last)
+	assert.NoError(t, err)
+	assert.False(t, result.HasMore)
+	assert.Empty(t, result.Cursor)
+	assert.Equal(t, []order{{ID: "5", Status: "new", Total: 10}}, last)
+	assert.Equal(t, "by-status", *inputs[2].IndexName)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "4"}, inputs[2].ExclusiveStartKey["order_id"])
+
+	_, err = client.QueryExpressionPage("orders", query, 0, "%%%", &page)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
@@ -2,8 +2,12 @@ package dynamodb
 
 import (
 	"context"
+	"crypto/cipher"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
 	"log"
 
@@ -12,84 +16,457 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
 )
 
 var (
 	// ErrNotFound is returned when no items could be found in Get or OldValue and similar operations.
 	ErrNotFound = errors.New("dynamo: no item found")
-	Tagkey      = "dynamo"
-	tables      = make(map[string]DynamoTable)
+	// ErrConditionFailed is returned when the condition of a conditional write is not met.
+	ErrConditionFailed = errors.New("dynamo: condition check failed")
+	// ErrVersionConflict is returned when a write on a versioned table finds the item at a different version than expected.
+	ErrVersionConflict = errors.New("dynamo: version conflict")
+	// ErrUnknownTable is returned when a method is called with a table that was not
+	// registered on the client.
+	ErrUnknownTable = errors.New("dynamo: unknown table")
+	Tagkey          = "dynamo"
 )
 
+// dynamoAPI is the subset of the DynamoDB SDK client used by Implementation.
+type dynamoAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error)
+	Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	TransactGetItems(ctx context.Context, params *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error)
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	DescribeTable(ctx context.Context, params *dynamodb.DescribeTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error)
+	UpdateTimeToLive(ctx context.Context, params *dynamodb.UpdateTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error)
+	DescribeTimeToLive(ctx context.Context, params *dynamodb.DescribeTimeToLiveInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error)
+}
+
 type Implementation struct {
-	client            *dynamodb.Client
-	partitionKeyField string
-	sortKeyField      *string
-	DynamoTables      map[string]DynamoTable
+	client  dynamoAPI
+	streams streamsAPI
+	// DynamoTables holds the tables registered on this client. Register tables with
+	// WithTable or RegisterTable, which are safe for concurrent use.
+	DynamoTables map[string]DynamoTable
+	mu           sync.RWMutex
+	cursorSecret []byte
+	cursorAEAD   cipher.AEAD
+	clock        func() time.Time
+	limiters     map[string]*rateLimiter
 }
 
 type DynamoTable struct {
 	TableName         string `json:"table_name"`
 	PartitionKeyField string `json:"primary_key_field"`
 	SortKeyField      string `json:"sort_key_field"`
-	MaxPageSize       int32  `json:"max_page_size"`
-	GlobalIndex       string `json:"global_index"`
+	// PartitionKeyType and SortKeyType declare the type of the key attributes.
+	// They default to KeyTypeString.
+	PartitionKeyType KeyType `json:"partition_key_type"`
+	SortKeyType      KeyType `json:"sort_key_type"`
+	MaxPageSize      int32   `json:"max_page_size"`
+	// GlobalIndexes and LocalIndexes are the secondary indexes of the table. Queries
+	// on any other index name fail with ErrUnknownIndex.
+	GlobalIndexes []Index `json:"global_indexes"`
+	LocalIndexes  []Index `json:"local_indexes"`
+	// Deprecated: GlobalIndex only registers the name of one global index, use
+	// GlobalIndexes instead.
+	GlobalIndex string `json:"global_index"`
+	// BillingMode is the billing mode of the table when it is provisioned, defaulting
+	// to BillingPayPerRequest. ReadCapacity and WriteCapacity only apply to
+	// BillingProvisioned and are shared by the global indexes.
+	BillingMode   BillingMode `json:"billing_mode"`
+	ReadCapacity  int64       `json:"read_capacity"`
+	WriteCapacity int64       `json:"write_capacity"`
+	// TTLField is the numeric attribute holding the epoch second at which DynamoDB
+	// expires an item. Save stamps it with the current time plus TTL when the item
+	// has no expiry yet. DynamoDB deletes expired items up to a few days late, so
+	// FilterExpired hides them from reads in the meantime.
+	TTLField      string        `json:"ttl_field"`
+	TTL           time.Duration `json:"ttl"`
+	FilterExpired bool          `json:"filter_expired"`
+	// CreatedAtField and UpdatedAtField enable write timestamps, stored as RFC 3339
+	// strings. Save and Update set the created timestamp when neither the item nor the
+	// stored item has one, and refresh the updated timestamp on every write. Batch and
+	// transactional saves cannot read the stored item and replace its created timestamp.
+	CreatedAtField string `json:"created_at_field"`
+	UpdatedAtField string `json:"updated_at_field"`
+	// VersionField enables optimistic locking: Save and Update increment this numeric
+	// attribute and fail with ErrVersionConflict when the item changed concurrently.
+	// Save is always guarded, Update and Delete only when given WithVersion.
+	VersionField string `json:"version_field"`
+	// EntityTypeField is the attribute holding the entity name of the items of a
+	// SingleTable. Defaults to entity_type.
+	EntityTypeField string `json:"entity_type_field"`
+	// RateLimit caps the read and write capacity units per second this client
+	// consumes on the table, slowing callers down before DynamoDB throttles them.
+	RateLimit RateLimit `json:"rate_limit"`
+	// Model is a value of the item type stored in the table. When set, the key, index,
+	// TTL and version fields are inferred from the options of its dynamo tags: hash,
+	// range, gsi-hash=<index>, gsi-range=<index>, lsi-range=<index>, ttl and version.
+	// Key types follow the Go types of the tagged fields.
+	Model interface{} `json:"-"`
 }
 
 type funcTable func(i *Implementation)
 
+// WithTable registers the table on the client. It panics when the definition is
+// invalid, see RegisterTable.
 func WithTable(arg DynamoTable) funcTable {
 	return func(i *Implementation) {
-		tables[arg.TableName] = DynamoTable{
-			TableName:         arg.TableName,
-			PartitionKeyField: arg.PartitionKeyField,
-			SortKeyField:      arg.SortKeyField,
-			MaxPageSize:       arg.MaxPageSize,
+		if err := i.RegisterTable(arg); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterTable adds the table definition to the client, replacing any table with
+// the same name. Tables are scoped to the client, so clients in the same process do
+// not share definitions. Definitions with a Model are completed from its tags and
+// fail with ErrInvalidModel when they disagree.
+func (i *Implementation) RegisterTable(arg DynamoTable) error {
+	arg, err := arg.withModel()
+	if err != nil {
+		return err
+	}
+	if arg.TableName == "" || arg.PartitionKeyField == "" {
+		return fmt.Errorf("dynamo: table definition needs a table name and a partition key field: %+v", arg)
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.DynamoTables == nil {
+		i.DynamoTables = make(map[string]DynamoTable)
+	}
+	previous, registered := i.DynamoTables[arg.TableName]
+	i.DynamoTables[arg.TableName] = arg
+	if registered && previous.RateLimit == arg.RateLimit {
+		// keep the limiter, with the rate it adapted to
+		return nil
+	}
+	if limiter := newRateLimiter(arg.RateLimit, i.now()); limiter != nil {
+		if i.limiters == nil {
+			i.limiters = make(map[string]*rateLimiter)
 		}
-		i.DynamoTables = tables
+		i.limiters[arg.TableName] = limiter
+	} else {
+		delete(i.limiters, arg.TableName)
 	}
+	return nil
+}
+
+// table returns the definition of a registered table, or ErrUnknownTable.
+func (i *Implementation) table(name string) (DynamoTable, error) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	def, ok := i.DynamoTables[name]
+	if !ok {
+		return DynamoTable{}, fmt.Errorf("%w: %s", ErrUnknownTable, name)
+	}
+	return def, nil
+}
+
+// primaryKey builds the key of an item of a registered table.
+func (i *Implementation) primaryKey(table string, partitionKey interface{}, sortKey ...interface{}) (map[string]types.AttributeValue, error) {
+	def, err := i.table(table)
+	if err != nil {
+		return nil, err
+	}
+	return def.primaryKey(partitionKey, sortKey...)
 }
 
 func NewDynamoClientv2(awsConfig aws.Config, funcTableArray ...funcTable) Client {
+	return NewImplementation(awsConfig, funcTableArray...)
+}
+
+// NewImplementation is NewDynamoClientv2 returning the concrete client, which is
+// needed by the features outside of the Client interface such as typed tables,
+// transactions, batch writes, provisioning, streams and the outbox.
+func NewImplementation(awsConfig aws.Config, funcTableArray ...funcTable) *Implementation {
 	var i Implementation
 	db := dynamodb.NewFromConfig(awsConfig)
 	for _, ft := range funcTableArray {
 		ft(&i)
 	}
-	i.client = db
+	i.client = &limitedAPI{dynamoAPI: db, client: &i, sleep: sleepContext}
+	i.streams = dynamodbstreams.NewFromConfig(awsConfig)
 	return &i
 }
 
-func (i *Implementation) Save(table string, values interface{}) error {
+func (i *Implementation) Save(table string, values interface{}, opts ...WriteOption) error {
+	return i.SaveWithContext(context.TODO(), table, values, opts...)
+}
+
+// SaveWithContext puts the given item in the table, propagating ctx to the request.
+func (i *Implementation) SaveWithContext(ctx context.Context, table string, values interface{}, opts ...WriteOption) error {
 	log.Printf("[DynamoDB] executing put query")
+	def, err := i.table(table)
+	if err != nil {
+		return err
+	}
 
 	item, err := attributevalue.MarshalMapWithOptions(values, func(h *attributevalue.EncoderOptions) {
 		h.TagKey = Tagkey
 	})
+	if err != nil {
+		return fmt.Errorf("failed to DynamoDB marshal Record, %w", err)
+	}
 
 	log.Printf("[DynamoDB] item to save: %s", item)
+	return i.putItem(ctx, def, item, values, opts...)
+}
+
+// putItem saves a marshalled item. values is the item before marshalling, which
+// receives the new version on versioned tables.
+func (i *Implementation) putItem(ctx context.Context, def DynamoTable, item map[string]types.AttributeValue, values interface{}, opts ...WriteOption) error {
+	var err error
+	ops := newWriteOptions(opts)
+	now := i.now()
+	keepCreated := def.CreatedAtField != "" && isZeroTimestamp(item[def.CreatedAtField])
+	def.stampExpiry(item, ops, now)
+	def.stampTimestamps(item, now)
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(def.TableName),
+		Item:      item,
+	}
+
+	var version int64
+	if def.VersionField != "" {
+		if err = versionTarget(def, values); err != nil {
+			return err
+		}
+		if version, err = itemVersion(item, def.VersionField); err != nil {
+			return err
+		}
+		item[def.VersionField] = &types.AttributeValueMemberN{Value: strconv.FormatInt(version+1, 10)}
+		ops.version = &version
+	}
+
+	if keepCreated {
+		// An item saved without a created timestamp only gets a new one when none is
+		// stored. Otherwise the failed condition returns the stored item, and the save
+		// is repeated with its timestamp, guarded against a concurrent change of it.
+		input.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+		err = i.putWithCondition(ctx, input, andCondition(ops.conditionBuilder(def), expression.AttributeNotExists(expression.Name(def.CreatedAtField))))
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) && !isZeroTimestamp(conditionErr.Item[def.CreatedAtField]) {
+			stored := conditionErr.Item[def.CreatedAtField]
+			item[def.CreatedAtField] = stored
+			err = i.putWithCondition(ctx, input, andCondition(ops.conditionBuilder(def), expression.Name(def.CreatedAtField).Equal(expression.Value(stored))))
+		}
+	} else {
+		err = i.putWithCondition(ctx, input, ops.conditionBuilder(def))
+	}
 	if err != nil {
-		panic(fmt.Sprintf("failed to DynamoDB marshal Record, %v", err))
+		return ops.writeError(def, err)
+	}
+	if def.VersionField != "" {
+		return setVersion(values, def.VersionField, version+1)
 	}
+	return nil
+}
 
-	_, err = i.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
-		TableName: aws.String(i.DynamoTables[table].TableName),
-		Item:      item,
-	})
+// putWithCondition sends the PutItem request guarded by the condition, if any.
+func (i *Implementation) putWithCondition(ctx context.Context, input *dynamodb.PutItemInput, condition *expression.ConditionBuilder) error {
+	input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues = nil, nil, nil
+	if condition != nil {
+		cond, err := expression.NewBuilder().WithCondition(*condition).Build()
+		if err != nil {
+			return err
+		}
+		input.ConditionExpression = cond.Condition()
+		input.ExpressionAttributeNames = cond.Names()
+		input.ExpressionAttributeValues = cond.Values()
+	}
+	_, err := i.client.PutItem(ctx, input)
 	return err
 }
 
-func (i *Implementation) getItem(table string, key map[string]types.AttributeValue, bindTo interface{}) error {
+// andCondition adds a condition to the optional condition of a write.
+func andCondition(condition *expression.ConditionBuilder, extra expression.ConditionBuilder) *expression.ConditionBuilder {
+	if condition != nil {
+		extra = condition.And(extra)
+	}
+	return &extra
+}
+
+// Delete removes the item with the given partition key.
+func (i *Implementation) Delete(table string, partitionKey string, opts ...WriteOption) error {
+	return i.DeleteWithContext(context.TODO(), table, partitionKey, opts...)
+}
+
+func (i *Implementation) DeleteWithContext(ctx context.Context, table string, partitionKey string, opts ...WriteOption) error {
+	return i.DeleteByKey(ctx, table, Key{PartitionKey: partitionKey}, opts...)
+}
+
+// DeleteWithSort removes the item with the given partition and sort key.
+func (i *Implementation) DeleteWithSort(table string, partitionKey string, sortKey string, opts ...WriteOption) error {
+	return i.DeleteWithSortWithContext(context.TODO(), table, partitionKey, sortKey, opts...)
+}
+
+func (i *Implementation) DeleteWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, opts ...WriteOption) error {
+	return i.DeleteByKey(ctx, table, Key{PartitionKey: partitionKey, SortKey: sortKey}, opts...)
+}
+
+// DeleteByKey is Delete, or DeleteWithSort when the key has a sort key, for key values
+// of the key types declared on the table.
+func (i *Implementation) DeleteByKey(ctx context.Context, table string, key Key, opts ...WriteOption) error {
+	log.Printf("[DynamoDB] executing delete query with [pk:%v][sk:%v]", key.PartitionKey, key.SortKey)
+
+	itemKey, err := i.primaryKey(table, key.PartitionKey, key.sortKeys()...)
+	if err != nil {
+		return err
+	}
+	return i.deleteItem(ctx, table, itemKey, opts...)
+}
+
+func (i *Implementation) deleteItem(ctx context.Context, table string, key map[string]types.AttributeValue, opts ...WriteOption) error {
+	def, err := i.table(table)
+	if err != nil {
+		return err
+	}
+	ops := newWriteOptions(opts)
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(def.TableName),
+		Key:       key,
+	}
+
+	if condition := ops.conditionBuilder(def); condition != nil {
+		cond, err := expression.NewBuilder().WithCondition(*condition).Build()
+		if err != nil {
+			return err
+		}
+		input.ConditionExpression = cond.Condition()
+		input.ExpressionAttributeNames = cond.Names()
+		input.ExpressionAttributeValues = cond.Values()
+	}
+	if ops.oldValue != nil {
+		input.ReturnValues = types.ReturnValueAllOld
+	}
+
+	out, err := i.client.DeleteItem(ctx, input)
+	if err != nil {
+		return ops.writeError(def, err)
+	}
+	if ops.oldValue == nil {
+		return nil
+	}
+	if out.Attributes == nil {
+		return ErrNotFound
+	}
+	return attributevalue.UnmarshalMapWithOptions(out.Attributes, ops.oldValue, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+}
+
+// Update applies the SET, REMOVE, ADD and DELETE actions of the update builder to the
+// item with the given partition key, creating it when it does not exist. When bindTo
+// is not nil the updated item is unmarshalled into it. On versioned tables the
+// version is incremented, but only checked when WithVersion is given.
+func (i *Implementation) Update(table string, partitionKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	return i.UpdateWithContext(context.TODO(), table, partitionKey, update, bindTo, opts...)
+}
+
+func (i *Implementation) UpdateWithContext(ctx context.Context, table string, partitionKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	return i.UpdateByKey(ctx, table, Key{PartitionKey: partitionKey}, update, bindTo, opts...)
+}
+
+// UpdateWithSort is Update for tables with a sort key.
+func (i *Implementation) UpdateWithSort(table string, partitionKey string, sortKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	return i.UpdateWithSortWithContext(context.TODO(), table, partitionKey, sortKey, update, bindTo, opts...)
+}
+
+func (i *Implementation) UpdateWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	return i.UpdateByKey(ctx, table, Key{PartitionKey: partitionKey, SortKey: sortKey}, update, bindTo, opts...)
+}
+
+// UpdateByKey is Update, or UpdateWithSort when the key has a sort key, for key values
+// of the key types declared on the table.
+func (i *Implementation) UpdateByKey(ctx context.Context, table string, key Key, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	log.Printf("[DynamoDB] executing update query with [pk:%v][sk:%v]", key.PartitionKey, key.SortKey)
+
+	itemKey, err := i.primaryKey(table, key.PartitionKey, key.sortKeys()...)
+	if err != nil {
+		return err
+	}
+	return i.updateItem(ctx, table, itemKey, update, bindTo, opts...)
+}
+
+// updateActions returns the update expression with the timestamp and version actions
+// of the table added. They are added to the built expression rather than the caller's
+// UpdateBuilder, which shares its state with every copy of the builder.
+func (d DynamoTable) updateActions(expr expression.Expression, now time.Time) (*string, map[string]string, map[string]types.AttributeValue) {
+	update, names, values := d.withTimestamps(expr.Update(), expr.Names(), expr.Values(), now)
+	if d.VersionField != "" {
+		update, names, values = withVersionIncrement(update, names, values, d.VersionField)
+	}
+	return update, names, values
+}
+
+func (i *Implementation) updateItem(ctx context.Context, table string, key map[string]types.AttributeValue, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	ops := newWriteOptions(opts)
+	returnValues, err := ops.updateReturnValues(bindTo)
+	if err != nil {
+		return err
+	}
+	def, err := i.table(table)
+	if err != nil {
+		return err
+	}
+	expr, err := ops.builder(def).WithUpdate(update).Build()
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName:           aws.String(def.TableName),
+		Key:                 key,
+		ConditionExpression: expr.Condition(),
+		ReturnValues:        returnValues,
+	}
+	input.UpdateExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues = def.updateActions(expr, i.now())
+
+	out, err := i.client.UpdateItem(ctx, input)
+	if err != nil {
+		return ops.writeError(def, err)
+	}
+
+	target := bindTo
+	if target == nil {
+		target = ops.oldValue
+	}
+	if target == nil {
+		return nil
+	}
+	if out.Attributes == nil {
+		return ErrNotFound
+	}
+	return attributevalue.UnmarshalMapWithOptions(out.Attributes, target, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+}
+
+func (i *Implementation) getItem(ctx context.Context, table string, key map[string]types.AttributeValue, bindTo interface{}) error {
 	log.Printf("[DynamoDB] executing get query")
+	def, err := i.table(table)
+	if err != nil {
+		return err
+	}
 
-	out, err := i.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
-		TableName: aws.String(i.DynamoTables[table].TableName),
+	out, err := i.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(def.TableName),
 		Key:       key,
 	})
 	if err != nil {
 		return err
 	}
-	if out.Item == nil {
+	if out.Item == nil || def.expired(out.Item, i.now()) {
 		return ErrNotFound
 	}
 	err = attributevalue.UnmarshalMapWithOptions(out.Item, &bindTo, func(options *attributevalue.DecoderOptions) {
@@ -99,52 +476,80 @@ func (i *Implementation) getItem(table string, key map[string]types.AttributeVal
 	return err
 }
 
-func (i *Implementation) getItemQuery(table string, key string, limit int32, bindTo interface{}) error {
+func (i *Implementation) getItemQuery(ctx context.Context, table string, partitionKey interface{}, limit int32, bindTo interface{}) error {
 	log.Printf("[DynamoDB] executing get query")
-	keyEx := expression.Key(i.DynamoTables[table].PartitionKeyField).Equal(expression.Value(key))
+	def, err := i.table(table)
+	if err != nil {
+		return err
+	}
+	key, err := keyValue(def.PartitionKeyField, def.PartitionKeyType, partitionKey)
+	if err != nil {
+		return err
+	}
+	keyEx := expression.Key(def.PartitionKeyField).Equal(expression.Value(key))
 	expr, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
 	if err != nil {
 		return err
 	}
-	out, err := i.client.Query(context.TODO(), &dynamodb.QueryInput{
-		TableName:                 aws.String(i.DynamoTables[table].TableName),
+	out, err := i.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:                 aws.String(def.TableName),
 		ExpressionAttributeNames:  expr.Names(),
 		ExpressionAttributeValues: expr.Values(),
 		KeyConditionExpression:    expr.KeyCondition(),
 		Limit:                     aws.Int32(limit),
 	})
-	if out != nil {
-		if len(out.Items) == 0 {
-			return ErrNotFound
-		}
-	}
 	if err != nil {
 		return err
 	}
+	items := def.live(out.Items, i.now())
+	if len(items) == 0 {
+		return ErrNotFound
+	}
 
-	err = attributevalue.UnmarshalListOfMapsWithOptions(out.Items, &bindTo, func(options *attributevalue.DecoderOptions) {
+	err = attributevalue.UnmarshalListOfMapsWithOptions(items, &bindTo, func(options *attributevalue.DecoderOptions) {
 		options.TagKey = Tagkey
 	})
 	return err
 }
 
 func (i *Implementation) ItemQueryExpression(table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	return i.ItemQueryExpressionWithContext(context.TODO(), table, globalIndex, query, pageSize, pageNumber, bindTo)
+}
+
+// ItemQueryExpressionWithContext is ItemQueryExpression with a caller supplied context,
+// which is propagated to every page request.
+func (i *Implementation) ItemQueryExpressionWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	_, err := i.itemQueryExpressionResult(ctx, table, globalIndex, query, pageSize, pageNumber, bindTo)
+	return err
+}
+
+// itemQueryExpressionResult runs ItemQueryExpression and returns what it read: the
+// result adds up the reads of every page requested, and its Cursor resumes the query
+// after the items returned.
+func (i *Implementation) itemQueryExpressionResult(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
 	log.Printf("[DynamoDB] executing get query") // Log indicating that a DynamoDB query is being executed
 
+	def, err := i.table(table)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := def.index(globalIndex); err != nil {
+		return nil, err
+	}
+
 	// Get MaxPageSize from table configuration or custom max page size
-	maxPageSize := getLimitPageSize(i.DynamoTables[table].MaxPageSize, pageSize)
+	maxPageSize := getLimitPageSize(def.MaxPageSize, pageSize)
 
 	var (
-		lastEvaluatedKey     map[string]types.AttributeValue   // Last evaluated key for pagination
-		itemsTotal           []map[string]types.AttributeValue // Total items retrieved
-		totalConsumeCapacity float64                           // Total consumed capacity
-		page                 int                               // Current page number
-		count                int32                             // Count of items retrieved
-		err                  error                             // Error
+		lastEvaluatedKey map[string]types.AttributeValue   // Last evaluated key for pagination
+		itemsTotal       []map[string]types.AttributeValue // Total items retrieved
+		page             int                               // Current page number
+		count            int32                             // Count of items retrieved
 	)
+	result := &QueryResult{} // Reads of every page
 
 	// Build the query input
-	queryInput := buildQueryInput(i.DynamoTables[table].TableName, globalIndex, query, lastEvaluatedKey)
+	queryInput := buildQueryInput(def.TableName, globalIndex, query, lastEvaluatedKey)
 
 	// Apply max limit item if pageSize is set
 	applyLimits(&queryInput.Limit, maxPageSize)
@@ -152,115 +557,188 @@ func (i *Implementation) ItemQueryExpression(table string, globalIndex string, q
 	for {
 		// Update ExclusiveStartKey with the next items to be fetched
 		queryInput.ExclusiveStartKey = lastEvaluatedKey
-		output, err := i.client.Query(context.TODO(), queryInput)
+		output, err := i.client.Query(ctx, queryInput)
 		if err != nil {
-			return fmt.Errorf("failed to query items: %w", err) // Return error if the query fails
+			return nil, fmt.Errorf("failed to query items: %w", err) // Return error if the query fails
 		}
 
-		totalConsumeCapacity += *output.ConsumedCapacity.CapacityUnits // Add consumed capacity
-		page++                                                         // Increment page number
+		result.add(output.Count, output.ScannedCount, output.ConsumedCapacity) // Add consumed capacity
+		page++                                                                 // Increment page number
 		// Append items to total items
 		itemsTotal = append(itemsTotal, output.Items...)
 		count += output.Count // Increment the count of items retrieved
+		// Update lastEvaluatedKey
+		lastEvaluatedKey = output.LastEvaluatedKey
 
 		// Check if the desired page has been setup and reached or there are no more items
 		if pageNumber > 0 && (hasReachDesiredPage(page, pageNumber) || output.LastEvaluatedKey == nil) {
 			itemsTotal = output.Items
-			logQueryStatus(query.Values(), totalConsumeCapacity, page, count) // Log the query status
 			break
 		}
 		// Check if the limit of items has been reached or there are no more items
 		if hasReachedLimit(count, maxPageSize, pageNumber) || output.LastEvaluatedKey == nil {
-			logQueryStatus(query.Values(), totalConsumeCapacity, page, count) // Log the query status
 			break
 		}
-		// Update lastEvaluatedKey
-		lastEvaluatedKey = output.LastEvaluatedKey
 
 		// Update the query limit
 		updateQueryLimit(&queryInput.Limit, &maxPageSize, count)
 	}
+	logQueryStatus(query.Values(), result.ConsumedCapacity, result.Pages, result.Count) // Log the query status
 
 	// Deserialize the list of attribute maps into bindTo
-	err = attributevalue.UnmarshalListOfMapsWithOptions(itemsTotal, &bindTo, func(options *attributevalue.DecoderOptions) {
+	items := def.live(itemsTotal, i.now())
+	err = attributevalue.UnmarshalListOfMapsWithOptions(items, &bindTo, func(options *attributevalue.DecoderOptions) {
 		options.TagKey = Tagkey
 	})
-	return err // Return error if any
-}
-
-func (i *Implementation) BatchGetItem(key map[string]types.KeysAndAttributes, values map[string]interface{}) error {
-	log.Printf("[DynamoDB] batch get query")
-	out, err := i.client.BatchGetItem(context.TODO(), &dynamodb.BatchGetItemInput{
-		RequestItems: key,
-	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	var bindList []interface{}
-	for i, o := range out.Responses {
-		for t, v := range values {
-			if i == t {
-				v, _ := v.([]interface{})
-				bindList := append(bindList, v[2])
-				err = attributevalue.UnmarshalListOfMapsWithOptions(o, &bindList, func(options *attributevalue.DecoderOptions) {
-					options.TagKey = Tagkey
-				})
-			}
-		}
+	result.Count = int32(len(items))
+	result.HasMore = lastEvaluatedKey != nil
+	if result.Cursor, err = i.encodeCursor(lastEvaluatedKey); err != nil {
+		return nil, err
 	}
-	return err
+	return result, nil
 }
 
 func (i *Implementation) GetOne(table string, partitionKey string, bindTo interface{}) error {
-	log.Printf("[DynamoDB] executing get query")
-	return i.getItem(table,
-		map[string]types.AttributeValue{
-			i.DynamoTables[table].PartitionKeyField: &types.AttributeValueMemberS{Value: partitionKey},
-		},
-		bindTo)
+	return i.GetOneWithContext(context.TODO(), table, partitionKey, bindTo)
+}
+
+func (i *Implementation) GetOneWithContext(ctx context.Context, table string, partitionKey string, bindTo interface{}) error {
+	return i.GetByKey(ctx, table, Key{PartitionKey: partitionKey}, bindTo)
 }
 
 func (i *Implementation) GetOneWithSort(table string, partitionKey string, sortKey string, bindTo interface{}) error {
-	log.Printf("[DynamoDB] executing get query with sortkey [pk:%s][sk:%s]", partitionKey, sortKey)
+	return i.GetOneWithSortWithContext(context.TODO(), table, partitionKey, sortKey, bindTo)
+}
 
-	return i.getItem(table,
-		map[string]types.AttributeValue{
-			i.partitionKeyField: &types.AttributeValueMemberS{Value: partitionKey},
-			*i.sortKeyField:     &types.AttributeValueMemberS{Value: sortKey},
-		},
-		bindTo)
+func (i *Implementation) GetOneWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, bindTo interface{}) error {
+	return i.GetByKey(ctx, table, Key{PartitionKey: partitionKey, SortKey: sortKey}, bindTo)
 }
 
-func (i *Implementation) QueryOne(table string, partitionKey string, limit int32, bindTo interface{}) error {
-	log.Printf("[DynamoDB] executing get query with [pk:%s]", partitionKey)
+// GetByKey is GetOne, or GetOneWithSort when the key has a sort key, for key values
+// of the key types declared on the table.
+func (i *Implementation) GetByKey(ctx context.Context, table string, key Key, bindTo interface{}) error {
+	log.Printf("[DynamoDB] executing get query with [pk:%v][sk:%v]", key.PartitionKey, key.SortKey)
 
-	return i.getItemQuery(table, partitionKey, limit, bindTo)
+	itemKey, err := i.primaryKey(table, key.PartitionKey, key.sortKeys()...)
+	if err != nil {
+		return err
+	}
+	return i.getItem(ctx, table, itemKey, bindTo)
+}
 
+func (i *Implementation) QueryOne(table string, partitionKey string, limit int32, bindTo interface{}) error {
+	return i.QueryOneWithContext(context.TODO(), table, partitionKey, limit, bindTo)
 }
 
-func (i *Implementation) BatchGetWithSort(values map[string]interface{}) error {
-	log.Printf("[DynamoDB] executing BatchGet query")
-	batchkeys := make(map[string]types.KeysAndAttributes)
-	for t, v := range values {
-		v, _ := v.([]interface{})
-		batchkeys[t] = types.KeysAndAttributes{
-			Keys: []map[string]types.AttributeValue{
-				{
-					i.DynamoTables[t].PartitionKeyField: &types.AttributeValueMemberS{Value: v[0].(string)},
-					i.DynamoTables[t].SortKeyField:      &types.AttributeValueMemberS{Value: v[1].(string)},
-				},
-			},
-		}
+func (i *Implementation) QueryOneWithContext(ctx context.Context, table string, partitionKey string, limit int32, bindTo interface{}) error {
+	return i.QueryOneByKey(ctx, table, partitionKey, limit, bindTo)
+}
 
-	}
+// QueryOneByKey is QueryOne for a partition key of the key type declared on the table.
+func (i *Implementation) QueryOneByKey(ctx context.Context, table string, partitionKey interface{}, limit int32, bindTo interface{}) error {
+	log.Printf("[DynamoDB] executing get query with [pk:%v]", partitionKey)
 
-	return i.BatchGetItem(batchkeys, values)
+	return i.getItemQuery(ctx, table, partitionKey, limit, bindTo)
 }
 
 // QueryExpression  returns multiple items by using a query expression
 func (i *Implementation) QueryExpression(table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	return i.QueryExpressionWithContext(context.TODO(), table, query, pageSize, pageNumber, bindTo)
+}
+
+func (i *Implementation) QueryExpressionWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	return i.ItemQueryExpressionWithContext(ctx, table, "", query, pageSize, pageNumber, bindTo)
+}
+
+// QueryExpressionResult is QueryExpression returning what the query read: the counts
+// and consumed capacity of every page requested, and a Cursor that resumes the query
+// after the items returned.
+func (i *Implementation) QueryExpressionResult(table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	return i.QueryExpressionResultWithContext(context.TODO(), table, query, pageSize, pageNumber, bindTo)
+}
+
+func (i *Implementation) QueryExpressionResultWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	return i.itemQueryExpressionResult(ctx, table, "", query, pageSize, pageNumber, bindTo)
+}
+
+// QueryExpressionPage returns one page of up to pageSize items (the table MaxPageSize
+// when 0) starting at cursor. The result holds the cursor of the next page and the
+// read statistics of the page. An empty cursor starts from the beginning.
+func (i *Implementation) QueryExpressionPage(table string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	return i.QueryExpressionPageWithContext(context.TODO(), table, query, pageSize, cursor, bindTo)
+}
+
+func (i *Implementation) QueryExpressionPageWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	return i.queryPage(ctx, table, "", query, pageSize, cursor, bindTo)
+}
+
+// QueryGSIPage is QueryExpressionPage on a global secondary index.
+func (i *Implementation) QueryGSIPage(table string, globalIndex string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	return i.QueryGSIPageWithContext(context.TODO(), table, globalIndex, query, pageSize, cursor, bindTo)
+}
+
+func (i *Implementation) QueryGSIPageWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	return i.queryPage(ctx, table, globalIndex, query, pageSize, cursor, bindTo)
+}
 
-	return i.ItemQueryExpression(table, "", query, pageSize, pageNumber, bindTo)
+func (i *Implementation) queryPage(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	log.Printf("[DynamoDB] executing page query")
+	def, err := i.table(table)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := def.index(globalIndex); err != nil {
+		return nil, err
+	}
+	queryInput := buildQueryInput(def.TableName, globalIndex, query, nil)
+	return i.readPage(ctx, def, queryInput, pageSize, cursor, bindTo)
+}
+
+// readPage reads from the key encoded in cursor until the page is full or there are
+// no more items, so each page costs only its own reads.
+func (i *Implementation) readPage(ctx context.Context, def DynamoTable, queryInput *dynamodb.QueryInput, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	startKey, err := i.decodeCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	limit := getLimitPageSize(def.MaxPageSize, pageSize)
+	queryInput.ExclusiveStartKey = startKey
+
+	result := &QueryResult{}
+	var items []map[string]types.AttributeValue
+	for {
+		applyLimits(&queryInput.Limit, limit-result.Count)
+		output, err := i.client.Query(ctx, queryInput)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query items: %w", err)
+		}
+		// expired items count as filtered out
+		live := def.live(output.Items, i.now())
+		result.add(int32(len(live)), output.ScannedCount, output.ConsumedCapacity)
+		items = append(items, live...)
+		queryInput.ExclusiveStartKey = output.LastEvaluatedKey
+
+		// without a limit a page is a single DynamoDB response
+		if output.LastEvaluatedKey == nil || limit <= 0 || result.Count >= limit {
+			break
+		}
+	}
+	logQueryStatus(queryInput.ExpressionAttributeValues, result.ConsumedCapacity, result.Pages, result.Count)
+
+	err = attributevalue.UnmarshalListOfMapsWithOptions(items, bindTo, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.HasMore = queryInput.ExclusiveStartKey != nil
+	if result.Cursor, err = i.encodeCursor(queryInput.ExclusiveStartKey); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 func applyLimits(limit **int32, limitMaxItems int32) {
@@ -285,7 +763,20 @@ func updateQueryLimit(limit **int32, limitMaxItems *int32, count int32) {
 }
 
 func (i *Implementation) QueryGSI(table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
-	return i.ItemQueryExpression(table, globalIndex, query, pageSize, pageNumber, bindTo)
+	return i.QueryGSIWithContext(context.TODO(), table, globalIndex, query, pageSize, pageNumber, bindTo)
+}
+
+func (i *Implementation) QueryGSIWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	return i.ItemQueryExpressionWithContext(ctx, table, globalIndex, query, pageSize, pageNumber, bindTo)
+}
+
+// QueryGSIResult is QueryGSI returning what the query read, as in QueryExpressionResult.
+func (i *Implementation) QueryGSIResult(table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	return i.QueryGSIResultWithContext(context.TODO(), table, globalIndex, query, pageSize, pageNumber, bindTo)
+}
+
+func (i *Implementation) QueryGSIResultWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	return i.itemQueryExpressionResult(ctx, table, globalIndex, query, pageSize, pageNumber, bindTo)
 }
 
 func buildQueryInput(tableName, globalIndex string, query expression.Expression, startKey map[string]types.AttributeValue) *dynamodb.QueryInput {
@@ -1,10 +1,101 @@
 package dynamodb
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
+	"errors"
+	"strconv"
+	"sync"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
 )
 
+var (
+	_ Client = (*Implementation)(nil)
+	_ Client = (*DynamoMock)(nil)
+	_ Client = (*MockClient)(nil)
+	_ Client = (*LocalClient)(nil)
+)
+
+type dynamoClientMock struct {
+	funcPutItem            func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error)
+	funcGetItem            func(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error)
+	funcQuery              func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error)
+	funcBatchGetItem       func(ctx context.Context, input *dynamodb.BatchGetItemInput) (*dynamodb.BatchGetItemOutput, error)
+	funcScan               func(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error)
+	funcDeleteItem         func(ctx context.Context, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error)
+	funcUpdateItem         func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error)
+	funcBatchWriteItem     func(ctx context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error)
+	funcTransactWriteItems func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error)
+	funcTransactGetItems   func(ctx context.Context, input *dynamodb.TransactGetItemsInput) (*dynamodb.TransactGetItemsOutput, error)
+	funcCreateTable        func(ctx context.Context, input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error)
+	funcDescribeTable      func(ctx context.Context, input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error)
+	funcUpdateTimeToLive   func(ctx context.Context, input *dynamodb.UpdateTimeToLiveInput) (*dynamodb.UpdateTimeToLiveOutput, error)
+	funcDescribeTimeToLive func(ctx context.Context, input *dynamodb.DescribeTimeToLiveInput) (*dynamodb.DescribeTimeToLiveOutput, error)
+}
+
+func (m *dynamoClientMock) PutItem(ctx context.Context, input *dynamodb.PutItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return m.funcPutItem(ctx, input)
+}
+
+func (m *dynamoClientMock) GetItem(ctx context.Context, input *dynamodb.GetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return m.funcGetItem(ctx, input)
+}
+
+func (m *dynamoClientMock) Query(ctx context.Context, input *dynamodb.QueryInput, _ ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return m.funcQuery(ctx, input)
+}
+
+func (m *dynamoClientMock) BatchGetItem(ctx context.Context, input *dynamodb.BatchGetItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return m.funcBatchGetItem(ctx, input)
+}
+
+func (m *dynamoClientMock) Scan(ctx context.Context, input *dynamodb.ScanInput, _ ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return m.funcScan(ctx, input)
+}
+
+func (m *dynamoClientMock) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return m.funcDeleteItem(ctx, input)
+}
+
+func (m *dynamoClientMock) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return m.funcUpdateItem(ctx, input)
+}
+
+func (m *dynamoClientMock) BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, _ ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return m.funcBatchWriteItem(ctx, input)
+}
+
+func (m *dynamoClientMock) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	return m.funcTransactWriteItems(ctx, input)
+}
+
+func (m *dynamoClientMock) TransactGetItems(ctx context.Context, input *dynamodb.TransactGetItemsInput, _ ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	return m.funcTransactGetItems(ctx, input)
+}
+
+func (m *dynamoClientMock) CreateTable(ctx context.Context, input *dynamodb.CreateTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error) {
+	return m.funcCreateTable(ctx, input)
+}
+
+func (m *dynamoClientMock) DescribeTable(ctx context.Context, input *dynamodb.DescribeTableInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTableOutput, error) {
+	return m.funcDescribeTable(ctx, input)
+}
+
+func (m *dynamoClientMock) UpdateTimeToLive(ctx context.Context, input *dynamodb.UpdateTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.UpdateTimeToLiveOutput, error) {
+	return m.funcUpdateTimeToLive(ctx, input)
+}
+
+func (m *dynamoClientMock) DescribeTimeToLive(ctx context.Context, input *dynamodb.DescribeTimeToLiveInput, _ ...func(*dynamodb.Options)) (*dynamodb.DescribeTimeToLiveOutput, error) {
+	return m.funcDescribeTimeToLive(ctx, input)
+}
+
+type ctxKey struct{}
+
 type person struct {
 	Id      string `json:"id"`
 	Name    string `json:"name"`
@@ -103,8 +194,23 @@ func TestDynamoLocalDevelopmentGetOneWithSort(t *testing.T) {
 	assert.Equal(t, "40", p.Age)
 
 	err = client.GetOneWithSort("person", "2", "John", &p)
-	assert.Error(t, err)
-	assert.Equal(t, "item not found", err.Error())
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestDynamoLocalDevelopmentEmptyTable(t *testing.T) {
+	client := NewLocalClient().WithTable(DynamoTable{TableName: "person", PartitionKeyField: "id"})
+
+	var p person
+	assert.ErrorIs(t, client.GetOne("person", "1", &p), ErrNotFound)
+	assert.ErrorIs(t, client.QueryOne("person", "1", 1, &p), ErrNotFound)
+
+	var people []person
+	result, err := client.Scan(context.Background(), "person", expression.Expression{}, &people)
+	assert.NoError(t, err)
+	assert.Zero(t, result.Count)
+	assert.Empty(t, people)
+
+	assert.ErrorIs(t, client.GetOne("unknown", "1", &p), ErrUnknownTable)
 }
 
 func TestDynamoLocalDevelopmentQueryOne(t *testing.T) {
@@ -125,3 +231,349 @@ func TestDynamoLocalDevelopmentQueryOne(t *testing.T) {
 	assert.Equal(t, "Suzanne", p.Name)
 	assert.Equal(t, "50", p.Age)
 }
+
+func TestImplementationPropagatesContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "request"))
+	defer cancel()
+
+	var seen []context.Context
+	i := &Implementation{
+		DynamoTables: map[string]DynamoTable{"person": {TableName: "person", PartitionKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcPutItem: func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+				seen = append(seen, ctx)
+				return &dynamodb.PutItemOutput{}, nil
+			},
+			funcGetItem: func(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+				seen = append(seen, ctx)
+				return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{
+					"id": &types.AttributeValueMemberS{Value: "1"},
+				}}, nil
+			},
+			funcQuery: func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+				seen = append(seen, ctx)
+				return &dynamodb.QueryOutput{
+					Items:            []map[string]types.AttributeValue{{"id": &types.AttributeValueMemberS{Value: "1"}}},
+					Count:            1,
+					ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)},
+				}, nil
+			},
+		},
+	}
+
+	var p person
+	assert.NoError(t, i.SaveWithContext(ctx, "person", person{Id: "1"}))
+	assert.NoError(t, i.GetOneWithContext(ctx, "person", "1", &p))
+	assert.NoError(t, i.QueryOneWithContext(ctx, "person", "1", 1, &[]person{}))
+
+	keyEx := expression.Key("id").Equal(expression.Value("1"))
+	query, err := expression.NewBuilder().WithKeyCondition(keyEx).Build()
+	assert.NoError(t, err)
+	err = i.QueryExpressionWithContext(ctx, "person", query, 10, 0, &[]person{})
+	assert.NoError(t, err)
+
+	assert.Len(t, seen, 4)
+	for _, c := range seen {
+		assert.Equal(t, "request", c.Value(ctxKey{}))
+	}
+}
+
+func TestDynamoLocalDevelopmentCanceledContext(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var p person
+	err := client.GetOneWithContext(ctx, "person", "1", &p)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = client.SaveWithContext(ctx, "person", person{Id: "5"})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestImplementationDeleteWithSort(t *testing.T) {
+	i := &Implementation{
+		DynamoTables: map[string]DynamoTable{"person": {TableName: "person", PartitionKeyField: "id", SortKeyField: "name"}},
+		client: &dynamoClientMock{
+			funcDeleteItem: func(ctx context.Context, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+				assert.Equal(t, map[string]types.AttributeValue{
+					"id":   &types.AttributeValueMemberS{Value: "1"},
+					"name": &types.AttributeValueMemberS{Value: "John"},
+				}, input.Key)
+				assert.Equal(t, "attribute_exists (#0)", *input.ConditionExpression)
+				assert.Equal(t, map[string]string{"#0": "id"}, input.ExpressionAttributeNames)
+				assert.Equal(t, types.ReturnValueAllOld, input.ReturnValues)
+				return &dynamodb.DeleteItemOutput{Attributes: map[string]types.AttributeValue{
+					"id":   &types.AttributeValueMemberS{Value: "1"},
+					"name": &types.AttributeValueMemberS{Value: "John"},
+				}}, nil
+			},
+		},
+	}
+
+	var old map[string]string
+	err := i.DeleteWithSort("person", "1", "John",
+		WithCondition(expression.AttributeExists(expression.Name("id"))),
+		WithOldValue(&old))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"id": "1", "name": "John"}, old)
+}
+
+func TestImplementationDeleteOldValueNotFound(t *testing.T) {
+	i := &Implementation{
+		DynamoTables: map[string]DynamoTable{"person": {TableName: "person", PartitionKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcDeleteItem: func(ctx context.Context, input *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+				assert.Nil(t, input.ConditionExpression)
+				return &dynamodb.DeleteItemOutput{}, nil
+			},
+		},
+	}
+
+	var p person
+	assert.NoError(t, i.Delete("person", "1"))
+	assert.ErrorIs(t, i.Delete("person", "1", WithOldValue(&p)), ErrNotFound)
+}
+
+func TestDynamoLocalDevelopmentDelete(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+			SortKeyField:      "name",
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	var old person
+	err := client.DeleteWithSort("person", "3", "Janet", WithOldValue(&old))
+	assert.NoError(t, err)
+	assert.Equal(t, "Tokyo", old.City)
+
+	var p person
+	err = client.GetOneWithSort("person", "3", "Janet", &p)
+	assert.Error(t, err)
+	err = client.GetOneWithSort("person", "3", "Suzanne", &p)
+	assert.NoError(t, err)
+
+	// deleting a missing item is a no-op
+	assert.NoError(t, client.Delete("person", "9"))
+	assert.ErrorIs(t, client.Delete("person", "9", WithOldValue(&old)), ErrNotFound)
+}
+
+func TestDynamoLocalDevelopmentConditionalDelete(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	err := client.Delete("person", "1", WithCondition(expression.Name("age").Equal(expression.Value("99"))))
+	assert.ErrorIs(t, err, ErrConditionFailed)
+	var condErr *types.ConditionalCheckFailedException
+	assert.ErrorAs(t, err, &condErr)
+
+	err = client.Delete("person", "1", WithCondition(expression.Name("age").Equal(expression.Value("30"))))
+	assert.NoError(t, err)
+
+	var p person
+	assert.Error(t, client.GetOne("person", "1", &p))
+}
+
+func TestImplementationUpdate(t *testing.T) {
+	i := &Implementation{
+		DynamoTables: map[string]DynamoTable{"person": {TableName: "person", PartitionKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcUpdateItem: func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+				assert.Equal(t, map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}}, input.Key)
+				assert.Equal(t, "SET #1 = :0\n", *input.UpdateExpression)
+				assert.Equal(t, "attribute_exists (#0)", *input.ConditionExpression)
+				assert.Equal(t, types.ReturnValueAllNew, input.ReturnValues)
+				return &dynamodb.UpdateItemOutput{Attributes: map[string]types.AttributeValue{
+					"id":   &types.AttributeValueMemberS{Value: "1"},
+					"City": &types.AttributeValueMemberS{Value: "Madrid"},
+				}}, nil
+			},
+		},
+	}
+
+	var p person
+	err := i.Update("person", "1",
+		expression.Set(expression.Name("City"), expression.Value("Madrid")),
+		&p,
+		WithCondition(expression.AttributeExists(expression.Name("id"))))
+	assert.NoError(t, err)
+	assert.Equal(t, "Madrid", p.City)
+
+	err = i.Update("person", "1", expression.Set(expression.Name("City"), expression.Value("Madrid")), &p, WithOldValue(&p))
+	assert.Error(t, err)
+}
+
+func TestDynamoLocalDevelopmentUpdate(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	var p person
+	err := client.Update("person", "1",
+		expression.Set(expression.Name("city"), expression.Value("Madrid")).
+			Remove(expression.Name("phone")),
+		&p)
+	assert.NoError(t, err)
+	assert.Equal(t, "Madrid", p.City)
+	assert.Equal(t, "John", p.Name)
+	assert.Equal(t, "", p.Phone)
+
+	var old person
+	err = client.Update("person", "1", expression.Set(expression.Name("city"), expression.Value("Paris")), nil, WithOldValue(&old))
+	assert.NoError(t, err)
+	assert.Equal(t, "Madrid", old.City)
+
+	err = client.Update("person", "1",
+		expression.Set(expression.Name("city"), expression.Value("Rome")),
+		nil,
+		WithCondition(expression.Name("city").Equal(expression.Value("London"))))
+	var condErr *types.ConditionalCheckFailedException
+	assert.ErrorAs(t, err, &condErr)
+
+	// updating a missing item creates it
+	err = client.Update("person", "7", expression.Set(expression.Name("name"), expression.Value("Ana")), &p)
+	assert.NoError(t, err)
+	assert.Equal(t, "7", p.Id)
+	assert.Equal(t, "Ana", p.Name)
+
+	var created person
+	assert.NoError(t, client.GetOne("person", "7", &created))
+	assert.Equal(t, "Ana", created.Name)
+}
+
+func TestImplementationConditionalSave(t *testing.T) {
+	var input *dynamodb.PutItemInput
+	i := &Implementation{
+		DynamoTables: map[string]DynamoTable{"person": {TableName: "person", PartitionKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcPutItem: func(ctx context.Context, in *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+				input = in
+				return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+			},
+		},
+	}
+
+	err := i.Save("person", person{Id: "1"}, IfNotExists(), WithCondition(expression.Name("age").LessThan(expression.Value("50"))))
+	assert.Equal(t, "(attribute_not_exists (#0)) AND (#1 < :0)", *input.ConditionExpression)
+	assert.Equal(t, map[string]string{"#0": "id", "#1": "age"}, input.ExpressionAttributeNames)
+
+	assert.ErrorIs(t, err, ErrConditionFailed)
+	assert.NotErrorIs(t, err, ErrVersionConflict)
+	var condErr *types.ConditionalCheckFailedException
+	assert.ErrorAs(t, err, &condErr)
+}
+
+func TestImplementationWriteErrorsPassThrough(t *testing.T) {
+	throttled := &types.ProvisionedThroughputExceededException{Message: aws.String("slow down")}
+	i := &Implementation{
+		DynamoTables: map[string]DynamoTable{"person": {TableName: "person", PartitionKeyField: "id"}},
+		client: &dynamoClientMock{
+			funcDeleteItem: func(ctx context.Context, in *dynamodb.DeleteItemInput) (*dynamodb.DeleteItemOutput, error) {
+				return nil, throttled
+			},
+		},
+	}
+
+	err := i.Delete("person", "1", WithCondition(expression.AttributeExists(expression.Name("id"))))
+	assert.Equal(t, throttled, err)
+	assert.NotErrorIs(t, err, ErrConditionFailed)
+}
+
+// unmarshallable fails to marshal into an attribute value.
+type unmarshallable struct{}
+
+func (unmarshallable) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return nil, errors.New("cannot marshal")
+}
+
+func TestImplementationSaveMarshalError(t *testing.T) {
+	i := &Implementation{
+		DynamoTables: map[string]DynamoTable{"person": {TableName: "person", PartitionKeyField: "id"}},
+		client:       &dynamoClientMock{},
+	}
+
+	err := i.Save("person", map[string]interface{}{"id": "1", "value": unmarshallable{}})
+	assert.ErrorContains(t, err, "failed to DynamoDB marshal Record, cannot marshal")
+}
+
+func TestDynamoLocalDevelopmentConditionalSave(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	err := client.Save("person", person{Id: "1", Name: "Other"}, IfNotExists())
+	assert.ErrorIs(t, err, ErrConditionFailed)
+
+	var p person
+	assert.NoError(t, client.GetOne("person", "1", &p))
+	assert.Equal(t, "John", p.Name)
+
+	assert.NoError(t, client.Save("person", person{Id: "8", Name: "New"}, IfNotExists()))
+	assert.NoError(t, client.Save("person", person{Id: "1", Name: "Johnny"}, WithCondition(expression.Name("name").Equal(expression.Value("John")))))
+	assert.NoError(t, client.GetOne("person", "1", &p))
+	assert.Equal(t, "Johnny", p.Name)
+
+	err = client.Update("person", "2", expression.Set(expression.Name("city"), expression.Value("Oslo")), nil, IfNotExists())
+	assert.ErrorIs(t, err, ErrConditionFailed)
+}
+
+func TestTablesAreScopedToTheClient(t *testing.T) {
+	first := NewImplementation(aws.Config{}, WithTable(DynamoTable{TableName: "person", PartitionKeyField: "id"}))
+	second := NewImplementation(aws.Config{}, WithTable(DynamoTable{TableName: "person", PartitionKeyField: "email"}))
+
+	assert.Equal(t, "id", first.DynamoTables["person"].PartitionKeyField)
+	assert.Equal(t, "email", second.DynamoTables["person"].PartitionKeyField)
+
+	assert.Error(t, first.RegisterTable(DynamoTable{TableName: "orders"}))
+	assert.Panics(t, func() { WithTable(DynamoTable{PartitionKeyField: "id"})(first) })
+
+	var wg sync.WaitGroup
+	for n := 0; n < 10; n++ {
+		wg.Add(2)
+		go func(n int) {
+			defer wg.Done()
+			assert.NoError(t, first.RegisterTable(DynamoTable{TableName: "table-" + strconv.Itoa(n), PartitionKeyField: "id"}))
+		}(n)
+		go func() {
+			defer wg.Done()
+			_, err := first.table("person")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, first.DynamoTables, 11)
+}
+
+func TestUnknownTable(t *testing.T) {
+	i := &Implementation{client: &dynamoClientMock{}}
+
+	var p person
+	assert.ErrorIs(t, i.Save("person", person{Id: "1"}), ErrUnknownTable)
+	assert.ErrorIs(t, i.GetOne("person", "1", &p), ErrUnknownTable)
+	assert.ErrorIs(t, i.DeleteWithSort("person", "1", "John"), ErrUnknownTable)
+	assert.ErrorIs(t, i.QueryOne("person", "1", 1, &[]person{}), ErrUnknownTable)
+	_, err := i.QueryKey("person", KeyQuery{PartitionKey: "1"}, &[]person{})
+	assert.ErrorIs(t, err, ErrUnknownTable)
+	_, err = i.Scan(context.Background(), "person", expression.Expression{}, &[]person{})
+	assert.ErrorIs(t, err, ErrUnknownTable)
+	assert.ErrorIs(t, i.BatchSave(context.Background(), "person", []person{{Id: "1"}}), ErrUnknownTable)
+	assert.EqualError(t, i.GetOne("person", "1", &p), "dynamo: unknown table: person")
+}
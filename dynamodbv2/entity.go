@@ -0,0 +1,343 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrUnknownEntity is returned when a SingleTable is given a Go type that is not
+// mapped to an entity, or reads an item whose entity type is not registered.
+var ErrUnknownEntity = errors.New("dynamo: unknown entity")
+
+// defaultEntityTypeField is the attribute holding the entity name when the table
+// definition has no EntityTypeField.
+const defaultEntityTypeField = "entity_type"
+
+// Entity maps a Go type to the items of a single-table design. The key templates
+// build the partition and sort keys of an item from its attributes, written as
+// {attribute} between literal text:
+//
+//	dynamodb.Entity{
+//		Name:         "order",
+//		Model:        Order{},
+//		PartitionKey: "USER#{user_id}",
+//		SortKey:      "ORDER#{date}#{id}",
+//	}
+type Entity struct {
+	// Name is stored in every item of the entity so that reads can decode it into
+	// the Model type.
+	Name         string
+	Model        interface{}
+	PartitionKey string
+	SortKey      string
+}
+
+// keyTemplate is a parsed key template: literal text alternating with attribute
+// names, starting and ending with literal text.
+type keyTemplate struct {
+	literals   []string
+	attributes []string
+}
+
+func parseKeyTemplate(template string) (keyTemplate, error) {
+	var t keyTemplate
+	rest := template
+	for {
+		literal, after, found := strings.Cut(rest, "{")
+		if strings.Contains(literal, "}") {
+			return t, fmt.Errorf("dynamo: unexpected } in key template %q", template)
+		}
+		t.literals = append(t.literals, literal)
+		if !found {
+			return t, nil
+		}
+		attribute, after, found := strings.Cut(after, "}")
+		if !found || attribute == "" || strings.Contains(attribute, "{") {
+			return t, fmt.Errorf("dynamo: invalid attribute in key template %q", template)
+		}
+		t.attributes = append(t.attributes, attribute)
+		rest = after
+	}
+}
+
+// render builds a key from the attributes of an item. Attributes must be strings or
+// numbers and must not be empty.
+func (t keyTemplate) render(item map[string]types.AttributeValue) (string, error) {
+	var b strings.Builder
+	for i, literal := range t.literals {
+		b.WriteString(literal)
+		if i == len(t.attributes) {
+			break
+		}
+		var value string
+		switch v := item[t.attributes[i]].(type) {
+		case *types.AttributeValueMemberS:
+			value = v.Value
+		case *types.AttributeValueMemberN:
+			value = v.Value
+		}
+		if value == "" {
+			return "", fmt.Errorf("%w: key attribute %s has no string or number value", ErrInvalidKey, t.attributes[i])
+		}
+		b.WriteString(value)
+	}
+	return b.String(), nil
+}
+
+// entity is a registered Entity with its parsed templates.
+type entity struct {
+	name         string
+	typ          reflect.Type
+	partitionKey keyTemplate
+	sortKey      keyTemplate
+}
+
+// SingleTable stores several entity types in one table, building their keys from
+// templates and decoding each item into the Go type of its entity.
+type SingleTable struct {
+	client    *Implementation
+	name      string
+	typeField string
+	sortKey   bool
+	byName    map[string]*entity
+	byType    map[reflect.Type]*entity
+}
+
+// NewSingleTable registers the table definition on the client and maps the entities
+// to it. The table keys, including those completed from its Model, must be strings.
+//
+//	app, err := dynamodb.NewSingleTable(client, dynamodb.DynamoTable{
+//		TableName:         "app",
+//		PartitionKeyField: "pk",
+//		SortKeyField:      "sk",
+//	}, users, orders)
+//	err = app.Save(ctx, User{ID: "123", Name: "Ada"})
+func NewSingleTable(client *Implementation, table DynamoTable, entities ...Entity) (*SingleTable, error) {
+	if err := client.RegisterTable(table); err != nil {
+		return nil, err
+	}
+	table, err := client.table(table.TableName)
+	if err != nil {
+		return nil, err
+	}
+	if (table.PartitionKeyType != "" && table.PartitionKeyType != KeyTypeString) ||
+		(table.SortKeyType != "" && table.SortKeyType != KeyTypeString) {
+		return nil, fmt.Errorf("dynamo: single table %s needs string keys", table.TableName)
+	}
+	s := &SingleTable{
+		client:    client,
+		name:      table.TableName,
+		typeField: table.EntityTypeField,
+		sortKey:   table.SortKeyField != "",
+		byName:    make(map[string]*entity),
+		byType:    make(map[reflect.Type]*entity),
+	}
+	if s.typeField == "" {
+		s.typeField = defaultEntityTypeField
+	}
+	for _, e := range entities {
+		if e.Name == "" || e.Model == nil || e.PartitionKey == "" {
+			return nil, fmt.Errorf("dynamo: entity needs a name, a model and a partition key template: %+v", e)
+		}
+		if s.sortKey != (e.SortKey != "") {
+			return nil, fmt.Errorf("dynamo: entity %s needs a sort key template only when table %s has a sort key", e.Name, s.name)
+		}
+		m, err := newModel(e.Model)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m.fields[s.typeField]; ok {
+			return nil, fmt.Errorf("dynamo: entity %s has an attribute named as the entity type field %s", e.Name, s.typeField)
+		}
+		registered := &entity{name: e.Name, typ: m.typ}
+		if registered.partitionKey, err = parseKeyTemplate(e.PartitionKey); err != nil {
+			return nil, err
+		}
+		if registered.sortKey, err = parseKeyTemplate(e.SortKey); err != nil {
+			return nil, err
+		}
+		for _, attribute := range append(registered.partitionKey.attributes, registered.sortKey.attributes...) {
+			if _, ok := m.fields[attribute]; !ok {
+				return nil, fmt.Errorf("dynamo: key template of entity %s uses %s, which %s does not have", e.Name, attribute, m.typ)
+			}
+		}
+		if s.byName[e.Name] != nil || s.byType[m.typ] != nil {
+			return nil, fmt.Errorf("dynamo: entity %s or type %s is mapped twice", e.Name, m.typ)
+		}
+		s.byName[e.Name] = registered
+		s.byType[m.typ] = registered
+	}
+	return s, nil
+}
+
+// Name returns the name of the underlying table.
+func (s *SingleTable) Name() string {
+	return s.name
+}
+
+// Save puts the item in the table with its keys and entity type set.
+func (s *SingleTable) Save(ctx context.Context, item interface{}, opts ...WriteOption) error {
+	def, err := s.client.table(s.name)
+	if err != nil {
+		return err
+	}
+	e, attributes, err := s.marshal(item)
+	if err != nil {
+		return err
+	}
+	key, err := s.keyOf(e, attributes)
+	if err != nil {
+		return err
+	}
+	attributes[def.PartitionKeyField] = &types.AttributeValueMemberS{Value: key.PartitionKey.(string)}
+	if s.sortKey {
+		attributes[def.SortKeyField] = &types.AttributeValueMemberS{Value: key.SortKey.(string)}
+	}
+	attributes[s.typeField] = &types.AttributeValueMemberS{Value: e.name}
+	return s.client.putItem(ctx, def, attributes, item, opts...)
+}
+
+// Get reads the item with the keys built from the attributes of item, which must
+// be a pointer, and decodes it into item. It returns ErrNotFound when the item is
+// missing or belongs to another entity.
+func (s *SingleTable) Get(ctx context.Context, item interface{}) error {
+	e, key, err := s.key(item)
+	if err != nil {
+		return err
+	}
+	var raw rawItem
+	if err := s.client.GetByKey(ctx, s.name, key, &raw); err != nil {
+		return err
+	}
+	if name, _ := raw[s.typeField].(*types.AttributeValueMemberS); name == nil || name.Value != e.name {
+		return ErrNotFound
+	}
+	return unmarshalItem(raw, item)
+}
+
+// Delete removes the item with the keys built from the attributes of item.
+func (s *SingleTable) Delete(ctx context.Context, item interface{}, opts ...WriteOption) error {
+	_, key, err := s.key(item)
+	if err != nil {
+		return err
+	}
+	return s.client.DeleteByKey(ctx, s.name, key, opts...)
+}
+
+// Key builds the keys of an item from its attributes, for use with the Implementation
+// methods that take keys such as BatchGet.
+func (s *SingleTable) Key(item interface{}) (Key, error) {
+	_, key, err := s.key(item)
+	return key, err
+}
+
+func (s *SingleTable) key(item interface{}) (*entity, Key, error) {
+	e, attributes, err := s.marshal(item)
+	if err != nil {
+		return nil, Key{}, err
+	}
+	key, err := s.keyOf(e, attributes)
+	return e, key, err
+}
+
+// keyOf renders the key templates of an entity with the attributes of an item.
+func (s *SingleTable) keyOf(e *entity, attributes map[string]types.AttributeValue) (Key, error) {
+	pk, err := e.partitionKey.render(attributes)
+	if err != nil {
+		return Key{}, err
+	}
+	if !s.sortKey {
+		return Key{PartitionKey: pk}, nil
+	}
+	sk, err := e.sortKey.render(attributes)
+	if err != nil {
+		return Key{}, err
+	}
+	return Key{PartitionKey: pk, SortKey: sk}, nil
+}
+
+// PartitionKey builds the partition key of an item, which only needs the attributes
+// used by the partition key template. It is the key to query the items of one
+// partition.
+func (s *SingleTable) PartitionKey(item interface{}) (string, error) {
+	e, attributes, err := s.marshal(item)
+	if err != nil {
+		return "", err
+	}
+	return e.partitionKey.render(attributes)
+}
+
+// Query returns the items of a partition matching the KeyQuery in sort key order,
+// each decoded into the Model type of its entity. Items are values, not pointers,
+// so they can be told apart with a type switch:
+//
+//	items, _, err := app.Query(ctx, dynamodb.KeyQuery{PartitionKey: "USER#123"})
+//	for _, item := range items {
+//		switch item := item.(type) {
+//		case User:
+//		case Order:
+//		}
+//	}
+//
+// It fails with ErrUnknownEntity when an item has no registered entity type.
+func (s *SingleTable) Query(ctx context.Context, query KeyQuery) ([]interface{}, *QueryResult, error) {
+	var raw []rawItem
+	result, err := s.client.QueryKeyWithContext(ctx, s.name, query, &raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	items := make([]interface{}, 0, len(raw))
+	for _, attributes := range raw {
+		name, _ := attributes[s.typeField].(*types.AttributeValueMemberS)
+		if name == nil || s.byName[name.Value] == nil {
+			return nil, nil, fmt.Errorf("%w: item of type %v in table %s", ErrUnknownEntity, attributes[s.typeField], s.name)
+		}
+		item := reflect.New(s.byName[name.Value].typ)
+		if err := unmarshalItem(attributes, item.Interface()); err != nil {
+			return nil, nil, err
+		}
+		items = append(items, item.Elem().Interface())
+	}
+	return items, result, nil
+}
+
+// marshal returns the entity of an item and its attributes.
+func (s *SingleTable) marshal(item interface{}) (*entity, map[string]types.AttributeValue, error) {
+	t := reflect.TypeOf(item)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	e := s.byType[t]
+	if e == nil {
+		return nil, nil, fmt.Errorf("%w: %v in table %s", ErrUnknownEntity, t, s.name)
+	}
+	attributes, err := attributevalue.MarshalMapWithOptions(item, func(options *attributevalue.EncoderOptions) {
+		options.TagKey = Tagkey
+	})
+	return e, attributes, err
+}
+
+// rawItem keeps the attributes of a read item so that it can be decoded once its
+// entity type is known.
+type rawItem map[string]types.AttributeValue
+
+func (r *rawItem) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	m, ok := av.(*types.AttributeValueMemberM)
+	if !ok {
+		return fmt.Errorf("dynamo: item is not a map: %T", av)
+	}
+	*r = m.Value
+	return nil
+}
+
+func unmarshalItem(attributes map[string]types.AttributeValue, bindTo interface{}) error {
+	return attributevalue.UnmarshalMapWithOptions(attributes, bindTo, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+}
@@ -0,0 +1,146 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type appUser struct {
+	ID   string `dynamo:"id"`
+	Name string `dynamo:"name"`
+}
+
+type appOrder struct {
+	UserID string  `dynamo:"user_id"`
+	Date   string  `dynamo:"date"`
+	Number int     `dynamo:"number"`
+	Total  float64 `dynamo:"total"`
+}
+
+func newAppTable() (*SingleTable, *[]map[string]types.AttributeValue) {
+	var stored []map[string]types.AttributeValue
+	client := NewImplementation(aws.Config{})
+	client.client = &dynamoClientMock{
+		funcPutItem: func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			stored = append(stored, input.Item)
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		funcGetItem: func(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			for _, item := range stored {
+				if assert.ObjectsAreEqual(item["pk"], input.Key["pk"]) && assert.ObjectsAreEqual(item["sk"], input.Key["sk"]) {
+					return &dynamodb.GetItemOutput{Item: item}, nil
+				}
+			}
+			return &dynamodb.GetItemOutput{}, nil
+		},
+		funcQuery: func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			var items []map[string]types.AttributeValue
+			for _, item := range stored {
+				if assert.ObjectsAreEqual(item["pk"], input.ExpressionAttributeValues[":0"]) {
+					items = append(items, item)
+				}
+			}
+			return &dynamodb.QueryOutput{Items: items, Count: int32(len(items))}, nil
+		},
+	}
+	app, err := NewSingleTable(client, DynamoTable{TableName: "app", PartitionKeyField: "pk", SortKeyField: "sk"},
+		Entity{Name: "user", Model: appUser{}, PartitionKey: "USER#{id}", SortKey: "PROFILE"},
+		Entity{Name: "order", Model: &appOrder{}, PartitionKey: "USER#{user_id}", SortKey: "ORDER#{date}#{number}"},
+	)
+	if err != nil {
+		panic(err)
+	}
+	return app, &stored
+}
+
+func TestSingleTable(t *testing.T) {
+	ctx := context.Background()
+	app, stored := newAppTable()
+
+	assert.NoError(t, app.Save(ctx, appUser{ID: "123", Name: "Ada"}))
+	assert.NoError(t, app.Save(ctx, &appOrder{UserID: "123", Date: "2024-01-01", Number: 9, Total: 12.5}))
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "USER#123"}, (*stored)[1]["pk"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "ORDER#2024-01-01#9"}, (*stored)[1]["sk"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "order"}, (*stored)[1]["entity_type"])
+
+	key, err := app.Key(appOrder{UserID: "123", Date: "2024-01-01", Number: 9})
+	assert.NoError(t, err)
+	assert.Equal(t, Key{PartitionKey: "USER#123", SortKey: "ORDER#2024-01-01#9"}, key)
+	pk, err := app.PartitionKey(appOrder{UserID: "123"})
+	assert.NoError(t, err)
+	assert.Equal(t, "USER#123", pk)
+
+	user := appUser{ID: "123"}
+	assert.NoError(t, app.Get(ctx, &user))
+	assert.Equal(t, "Ada", user.Name)
+	assert.ErrorIs(t, app.Get(ctx, &appUser{ID: "456"}), ErrNotFound)
+
+	items, _, err := app.Query(ctx, KeyQuery{PartitionKey: pk})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		appUser{ID: "123", Name: "Ada"},
+		appOrder{UserID: "123", Date: "2024-01-01", Number: 9, Total: 12.5},
+	}, items)
+
+	_, err = app.Key(appOrder{UserID: "123"})
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	assert.ErrorIs(t, app.Save(ctx, person{}), ErrUnknownEntity)
+	*stored = append(*stored, map[string]types.AttributeValue{
+		"pk":          &types.AttributeValueMemberS{Value: "USER#123"},
+		"entity_type": &types.AttributeValueMemberS{Value: "invoice"},
+	})
+	_, _, err = app.Query(ctx, KeyQuery{PartitionKey: pk})
+	assert.ErrorIs(t, err, ErrUnknownEntity)
+}
+
+func TestSingleTableRejectsInvalidEntities(t *testing.T) {
+	table := DynamoTable{TableName: "app", PartitionKeyField: "pk", SortKeyField: "sk"}
+	tests := map[string][]Entity{
+		"missing sort key template": {{Name: "user", Model: appUser{}, PartitionKey: "USER#{id}"}},
+		"unknown attribute":         {{Name: "user", Model: appUser{}, PartitionKey: "USER#{email}", SortKey: "PROFILE"}},
+		"unclosed attribute":        {{Name: "user", Model: appUser{}, PartitionKey: "USER#{id", SortKey: "PROFILE"}},
+		"type mapped twice": {
+			{Name: "user", Model: appUser{}, PartitionKey: "USER#{id}", SortKey: "PROFILE"},
+			{Name: "admin", Model: &appUser{}, PartitionKey: "ADMIN#{id}", SortKey: "PROFILE"},
+		},
+	}
+	for name, entities := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewSingleTable(NewImplementation(aws.Config{}), table, entities...)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestSingleTableKeysFromModel(t *testing.T) {
+	type row struct {
+		PK string `dynamo:"pk,hash"`
+		SK string `dynamo:"sk,range"`
+	}
+	user := Entity{Name: "user", Model: appUser{}, PartitionKey: "USER#{id}", SortKey: "PROFILE"}
+
+	app, err := NewSingleTable(NewImplementation(aws.Config{}), DynamoTable{TableName: "app", Model: row{}}, user)
+	assert.NoError(t, err)
+	key, err := app.Key(appUser{ID: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, Key{PartitionKey: "USER#1", SortKey: "PROFILE"}, key)
+
+	user.SortKey = ""
+	_, err = NewSingleTable(NewImplementation(aws.Config{}), DynamoTable{TableName: "app", Model: row{}}, user)
+	assert.ErrorContains(t, err, "needs a sort key template only when table app has a sort key")
+
+	type numbered struct {
+		PK string `dynamo:"pk,hash"`
+		SK int    `dynamo:"sk,range"`
+	}
+	_, err = NewSingleTable(NewImplementation(aws.Config{}), DynamoTable{TableName: "app", Model: numbered{}}, user)
+	assert.ErrorContains(t, err, "single table app needs string keys")
+
+	_, err = NewSingleTable(NewImplementation(aws.Config{}), DynamoTable{TableName: "app"}, user)
+	assert.Error(t, err)
+}
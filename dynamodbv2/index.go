@@ -0,0 +1,62 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ProjectionType is the set of attributes copied into a secondary index.
+type ProjectionType string
+
+const (
+	ProjectionAll      ProjectionType = "ALL"
+	ProjectionKeysOnly ProjectionType = "KEYS_ONLY"
+	ProjectionInclude  ProjectionType = "INCLUDE"
+)
+
+// ErrUnknownIndex is returned when a query names an index that is not registered
+// in the table definition.
+var ErrUnknownIndex = errors.New("dynamo: unknown index")
+
+// Index is a secondary index of a table. Local secondary indexes share the partition
+// key of the table, so their PartitionKeyField and PartitionKeyType are ignored.
+type Index struct {
+	Name              string  `json:"name"`
+	PartitionKeyField string  `json:"primary_key_field"`
+	PartitionKeyType  KeyType `json:"partition_key_type"`
+	SortKeyField      string  `json:"sort_key_field"`
+	SortKeyType       KeyType `json:"sort_key_type"`
+	// Projection defaults to ProjectionAll. NonKeyAttributes lists the attributes
+	// projected with ProjectionInclude.
+	Projection       ProjectionType `json:"projection"`
+	NonKeyAttributes []string       `json:"non_key_attributes"`
+}
+
+// index returns the key schema used to query the table, or the named index when
+// name is not empty.
+func (d DynamoTable) index(name string) (Index, error) {
+	if name == "" {
+		return Index{
+			PartitionKeyField: d.PartitionKeyField,
+			PartitionKeyType:  d.PartitionKeyType,
+			SortKeyField:      d.SortKeyField,
+			SortKeyType:       d.SortKeyType,
+		}, nil
+	}
+	for _, index := range d.GlobalIndexes {
+		if index.Name == name {
+			return index, nil
+		}
+	}
+	for _, index := range d.LocalIndexes {
+		if index.Name == name {
+			index.PartitionKeyField = d.PartitionKeyField
+			index.PartitionKeyType = d.PartitionKeyType
+			return index, nil
+		}
+	}
+	if name == d.GlobalIndex {
+		return Index{Name: name}, nil
+	}
+	return Index{}, fmt.Errorf("%w: %s on table %s", ErrUnknownIndex, name, d.TableName)
+}
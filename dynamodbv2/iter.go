@@ -0,0 +1,138 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// queryPages lazily requests the pages of a query. A page is only requested when the
+// previous one was consumed, so stopping the iteration stops the requests.
+func (i *Implementation) queryPages(ctx context.Context, table string, globalIndex string, query expression.Expression) iter.Seq2[[]map[string]types.AttributeValue, error] {
+	return func(yield func([]map[string]types.AttributeValue, error) bool) {
+		def, err := i.table(table)
+		if err == nil {
+			_, err = def.index(globalIndex)
+		}
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		queryInput := buildQueryInput(def.TableName, globalIndex, query, nil)
+		applyLimits(&queryInput.Limit, def.MaxPageSize)
+		for {
+			output, err := i.client.Query(ctx, queryInput)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to query items: %w", err))
+				return
+			}
+			if !yield(def.live(output.Items, i.now()), nil) || output.LastEvaluatedKey == nil {
+				return
+			}
+			queryInput.ExclusiveStartKey = output.LastEvaluatedKey
+		}
+	}
+}
+
+// scanPages lazily requests the pages of a sequential scan.
+func (i *Implementation) scanPages(ctx context.Context, table string, filter expression.Expression) iter.Seq2[[]map[string]types.AttributeValue, error] {
+	return func(yield func([]map[string]types.AttributeValue, error) bool) {
+		def, err := i.table(table)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		err = i.scanSegment(ctx, def, filter, 0, 1, func(segment int, output *dynamodb.ScanOutput) error {
+			if !yield(output.Items, nil) {
+				return ErrStopScan
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, ErrStopScan) {
+			yield(nil, err)
+		}
+	}
+}
+
+// Item is an item read by QueryItems, QueryGSIItems or ScanItems, kept as its
+// DynamoDB attributes so it can be inspected before it is decoded.
+type Item map[string]types.AttributeValue
+
+// Unmarshal decodes the item into bindTo, which must be a pointer.
+func (it Item) Unmarshal(bindTo interface{}) error {
+	return attributevalue.UnmarshalMapWithOptions(it, bindTo, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+}
+
+// QueryItems iterates over every item matching the query expression. Pages are
+// requested as the items are consumed, up to the table MaxPageSize items each, and
+// breaking out of the loop stops further requests.
+//
+//	for item, err := range client.QueryItems(ctx, "orders", query) {
+//		if err != nil {
+//			return err
+//		}
+//		var o Order
+//		if err := item.Unmarshal(&o); err != nil {
+//			return err
+//		}
+//	}
+func (i *Implementation) QueryItems(ctx context.Context, table string, query expression.Expression) iter.Seq2[Item, error] {
+	return pageItems(i.queryPages(ctx, table, "", query))
+}
+
+// QueryGSIItems is QueryItems on the given index.
+func (i *Implementation) QueryGSIItems(ctx context.Context, table string, globalIndex string, query expression.Expression) iter.Seq2[Item, error] {
+	return pageItems(i.queryPages(ctx, table, globalIndex, query))
+}
+
+// ScanItems iterates over every item of the table matching the filter of the
+// expression, with a sequential scan that stops when the loop ends.
+func (i *Implementation) ScanItems(ctx context.Context, table string, filter expression.Expression) iter.Seq2[Item, error] {
+	return pageItems(i.scanPages(ctx, table, filter))
+}
+
+// Decode decodes every item of an iterator such as QueryItems into T. Iteration ends
+// after the first error.
+//
+//	for order, err := range dynamodb.Decode[Order](client.QueryItems(ctx, "orders", query)) {
+//		...
+//	}
+func Decode[T any](items iter.Seq2[Item, error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for item, err := range items {
+			var value T
+			if err == nil {
+				err = item.Unmarshal(&value)
+			}
+			if !yield(value, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// pageItems yields the items of every page one by one. Iteration ends after the
+// first error.
+func pageItems(pages iter.Seq2[[]map[string]types.AttributeValue, error]) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		for page, err := range pages {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, av := range page {
+				if !yield(av, nil) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// KeyType is the DynamoDB type of a key attribute.
+type KeyType string
+
+const (
+	KeyTypeString KeyType = "S"
+	KeyTypeNumber KeyType = "N"
+	KeyTypeBinary KeyType = "B"
+)
+
+// ErrInvalidKey is returned when a key value does not match the key type declared
+// in the table definition.
+var ErrInvalidKey = errors.New("dynamo: invalid key")
+
+// Key identifies an item of a table. SortKey is only used on tables with a sort key.
+// Values must match the key types of the table: a string for KeyTypeString, any
+// integer or float for KeyTypeNumber and a []byte for KeyTypeBinary.
+type Key struct {
+	PartitionKey interface{}
+	SortKey      interface{}
+}
+
+// primaryKey builds the key of an item from its partition key and, when given, its
+// sort key.
+func (d DynamoTable) primaryKey(partitionKey interface{}, sortKey ...interface{}) (map[string]types.AttributeValue, error) {
+	pk, err := keyValue(d.PartitionKeyField, d.PartitionKeyType, partitionKey)
+	if err != nil {
+		return nil, err
+	}
+	key := map[string]types.AttributeValue{d.PartitionKeyField: pk}
+	if len(sortKey) > 0 {
+		sk, err := keyValue(d.SortKeyField, d.SortKeyType, sortKey[0])
+		if err != nil {
+			return nil, err
+		}
+		key[d.SortKeyField] = sk
+	}
+	return key, nil
+}
+
+// sortKeys returns the sort key as the optional argument of primaryKey.
+func (k Key) sortKeys() []interface{} {
+	if k.SortKey == nil {
+		return nil
+	}
+	return []interface{}{k.SortKey}
+}
+
+// itemKey builds the key of an item from a Key, adding the sort key when the table
+// defines one.
+func (d DynamoTable) itemKey(key Key) (map[string]types.AttributeValue, error) {
+	if d.SortKeyField != "" {
+		return d.primaryKey(key.PartitionKey, key.SortKey)
+	}
+	return d.primaryKey(key.PartitionKey)
+}
+
+// keyValue converts a key value to an attribute of the given type, failing with
+// ErrInvalidKey when the Go type of the value does not match.
+func keyValue(field string, keyType KeyType, value interface{}) (types.AttributeValue, error) {
+	v := reflect.ValueOf(value)
+	switch keyType {
+	case KeyTypeString, "":
+		if v.Kind() == reflect.String {
+			return &types.AttributeValueMemberS{Value: v.String()}, nil
+		}
+	case KeyTypeNumber:
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return &types.AttributeValueMemberN{Value: strconv.FormatInt(v.Int(), 10)}, nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return &types.AttributeValueMemberN{Value: strconv.FormatUint(v.Uint(), 10)}, nil
+		case reflect.Float32, reflect.Float64:
+			return &types.AttributeValueMemberN{Value: strconv.FormatFloat(v.Float(), 'f', -1, 64)}, nil
+		}
+	case KeyTypeBinary:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return &types.AttributeValueMemberB{Value: v.Bytes()}, nil
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown key type %q for %s", ErrInvalidKey, keyType, field)
+	}
+	if keyType == "" {
+		keyType = KeyTypeString
+	}
+	return nil, fmt.Errorf("%w: %s is of type %s, got %T", ErrInvalidKey, field, keyType, value)
+}
@@ -0,0 +1,118 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type userID int64
+
+func TestKeyValue(t *testing.T) {
+	tests := []struct {
+		keyType KeyType
+		value   interface{}
+		want    types.AttributeValue
+	}{
+		{"", "a", &types.AttributeValueMemberS{Value: "a"}},
+		{KeyTypeString, "a", &types.AttributeValueMemberS{Value: "a"}},
+		{KeyTypeNumber, 42, &types.AttributeValueMemberN{Value: "42"}},
+		{KeyTypeNumber, userID(-7), &types.AttributeValueMemberN{Value: "-7"}},
+		{KeyTypeNumber, uint8(3), &types.AttributeValueMemberN{Value: "3"}},
+		{KeyTypeNumber, 1.5, &types.AttributeValueMemberN{Value: "1.5"}},
+		{KeyTypeBinary, []byte{1, 2}, &types.AttributeValueMemberB{Value: []byte{1, 2}}},
+	}
+	for _, tt := range tests {
+		got, err := keyValue("id", tt.keyType, tt.value)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+
+	_, err := keyValue("id", KeyTypeNumber, "42")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	assert.EqualError(t, err, "dynamo: invalid key: id is of type N, got string")
+	_, err = keyValue("id", "", 42)
+	assert.EqualError(t, err, "dynamo: invalid key: id is of type S, got int")
+	_, err = keyValue("id", KeyTypeBinary, "a")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = keyValue("id", "X", "a")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestImplementationNumberKeys(t *testing.T) {
+	var input *dynamodb.GetItemInput
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"events": {
+			TableName:         "events",
+			PartitionKeyField: "device",
+			PartitionKeyType:  KeyTypeNumber,
+			SortKeyField:      "at",
+			SortKeyType:       KeyTypeBinary,
+		}},
+		client: &dynamoClientMock{
+			funcGetItem: func(ctx context.Context, in *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+				input = in
+				return &dynamodb.GetItemOutput{Item: in.Key}, nil
+			},
+			funcBatchWriteItem: func(ctx context.Context, in *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+				return &dynamodb.BatchWriteItemOutput{}, nil
+			},
+		},
+	}
+
+	var event map[string]interface{}
+	assert.NoError(t, client.GetByKey(context.Background(), "events", Key{PartitionKey: 7, SortKey: []byte("t1")}, &event))
+	assert.Equal(t, map[string]types.AttributeValue{
+		"device": &types.AttributeValueMemberN{Value: "7"},
+		"at":     &types.AttributeValueMemberB{Value: []byte("t1")},
+	}, input.Key)
+
+	err := client.GetByKey(context.Background(), "events", Key{PartitionKey: "7", SortKey: []byte("t1")}, &event)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	err = client.DeleteWithSort("events", "7", "t1")
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	err = client.BatchDelete(context.Background(), "events", []Key{{PartitionKey: 1, SortKey: []byte("a")}, {PartitionKey: "2", SortKey: []byte("b")}})
+	var batchErr *BatchError
+	assert.True(t, errors.As(err, &batchErr))
+	assert.Equal(t, 1, batchErr.Failures[0].Index)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	err = client.WriteTransaction().DeleteWithSort("events", "7", []byte("t1")).Commit(context.Background())
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestDynamoLocalDevelopmentNumberKeys(t *testing.T) {
+	type counter struct {
+		ID    int    `json:"id"`
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "counters",
+			PartitionKeyField: "id",
+			PartitionKeyType:  KeyTypeNumber,
+		})
+
+	assert.NoError(t, client.Save("counters", counter{ID: 1, Name: "a", Count: 3}))
+
+	var c counter
+	assert.NoError(t, client.GetByKey(context.Background(), "counters", Key{PartitionKey: 1}, &c))
+	assert.Equal(t, "a", c.Name)
+
+	err := client.GetOne("counters", "1", &c)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	update := expression.Set(expression.Name("count"), expression.Value(4))
+	assert.NoError(t, client.UpdateByKey(context.Background(), "counters", Key{PartitionKey: 1}, update, &c))
+	assert.Equal(t, 4, c.Count)
+
+	assert.NoError(t, client.DeleteByKey(context.Background(), "counters", Key{PartitionKey: int64(1)}))
+	assert.ErrorIs(t, client.GetByKey(context.Background(), "counters", Key{PartitionKey: 1}, &c), ErrNotFound)
+}
@@ -1,15 +1,28 @@
 package dynamodb
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"iter"
+	"log"
 	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 type LocalClient struct {
 	data          map[string][]interface{}
 	preloadedFile string
 	tables        map[string]*DynamoTable
+	clock         func() time.Time
 }
 
 type LocalTableConfig struct {
@@ -24,12 +37,30 @@ func NewLocalClient() *LocalClient {
 	}
 }
 
-// WithTable initializes the given table with the given config.
+// WithTable initializes the given table with the given config. It panics when the
+// Model of the table is invalid.
 func (l *LocalClient) WithTable(table DynamoTable) *LocalClient {
+	table, err := table.withModel()
+	if err != nil {
+		panic(err)
+	}
 	l.tables[table.TableName] = &table
 	return l
 }
 
+// WithClock sets the clock used for timestamps and TTL expiry. Defaults to time.Now.
+func (l *LocalClient) WithClock(clock func() time.Time) *LocalClient {
+	l.clock = clock
+	return l
+}
+
+func (l *LocalClient) now() time.Time {
+	if l.clock != nil {
+		return l.clock()
+	}
+	return time.Now()
+}
+
 // WithPreloadedItems loads the given file into the local client.
 // The file should be a JSON file with the following format:
 // [{...}, {...}]
@@ -38,20 +69,20 @@ func (l *LocalClient) WithPreloadedItems(table string, filePath string) *LocalCl
 	//add to l.data
 	mydir, err := os.Getwd()
 	if err != nil {
-		fmt.Println(err)
+		log.Printf("[DynamoDB] error getting working directory: %s", err.Error())
 	}
 
 	fileName := mydir + filePath
 	configFile, err := os.Open(fileName)
 	if err != nil {
-		fmt.Println("opening config file error: ", err.Error())
+		log.Printf("[DynamoDB] error opening preloaded file: %s", err.Error())
 		return nil
 	}
 
 	var items []map[string]interface{}
 	jsonParser := json.NewDecoder(configFile)
 	if err := jsonParser.Decode(&items); err != nil {
-		fmt.Println("parsing preloaded file", err.Error())
+		log.Printf("[DynamoDB] error parsing preloaded file: %s", err.Error())
 	}
 
 	l.data[table] = []interface{}{}
@@ -62,107 +93,723 @@ func (l *LocalClient) WithPreloadedItems(table string, filePath string) *LocalCl
 	return l
 }
 
-func (l *LocalClient) Save(table string, values interface{}) error {
-	l.data[table] = append(l.data[table], values)
-	return nil
+func (l *LocalClient) Save(table string, values interface{}, opts ...WriteOption) error {
+	return l.SaveWithContext(context.Background(), table, values, opts...)
 }
 
-func (l *LocalClient) GetOne(table string, partitionKey string, bindTo interface{}) error {
-	if l.data[table] == nil {
-		return fmt.Errorf("table %s not found", table)
+func (l *LocalClient) SaveWithContext(ctx context.Context, table string, values interface{}, opts ...WriteOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	if l.tables[table] == nil {
-		return fmt.Errorf("table %s not initialized", table)
+	l.expire(table)
+	def := l.tables[table]
+	if def == nil && len(opts) == 0 {
+		l.data[table] = append(l.data[table], values)
+		return nil
 	}
-	partitionField := l.tables[table].PartitionKeyField
-	for _, item := range l.data[table] {
-		var itemMap map[string]interface{}
-		inrec, _ := json.Marshal(item)
-		json.Unmarshal(inrec, &itemMap)
-		if itemMap[partitionField] == partitionKey {
-			b, err := json.Marshal(itemMap)
-			if err != nil {
-				return err
+	if def == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownTable, table)
+	}
+
+	// as PutItem does, replace the item stored with the same key
+	var itemMap map[string]interface{}
+	inrec, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(inrec, &itemMap); err != nil {
+		return err
+	}
+	key := map[string]interface{}{def.PartitionKeyField: itemMap[def.PartitionKeyField]}
+	if def.SortKeyField != "" {
+		key[def.SortKeyField] = itemMap[def.SortKeyField]
+	}
+	index, current := l.findItem(table, key)
+
+	ops := newWriteOptions(opts)
+	now := l.now()
+	if def.TTLField != "" {
+		if ops.expiresAt != nil {
+			itemMap[def.TTLField] = float64(ops.expiresAt.Unix())
+		} else if expiresAt, _ := itemMap[def.TTLField].(float64); def.TTL > 0 && expiresAt == 0 {
+			itemMap[def.TTLField] = float64(now.Add(def.TTL).Unix())
+		}
+	}
+	if def.CreatedAtField != "" {
+		if createdAt, _ := itemMap[def.CreatedAtField].(string); isZeroTimestamp(&types.AttributeValueMemberS{Value: createdAt}) {
+			// as the DynamoDB client does, keep the created timestamp of a stored item
+			itemMap[def.CreatedAtField] = timestamp(now)
+			if stored, ok := current[def.CreatedAtField]; ok && stored != nil {
+				itemMap[def.CreatedAtField] = stored
 			}
-			return json.Unmarshal(b, bindTo)
 		}
 	}
+	if def.UpdatedAtField != "" {
+		itemMap[def.UpdatedAtField] = timestamp(now)
+	}
+	var version int64
+	if def.VersionField != "" {
+		if err := versionTarget(*def, values); err != nil {
+			return err
+		}
+		if v, ok := itemMap[def.VersionField].(float64); ok {
+			version = int64(v)
+		}
+		itemMap[def.VersionField] = version + 1
+		ops.version = &version
+	}
+	if condition := ops.conditionBuilder(*def); condition != nil {
+		cond, err := expression.NewBuilder().WithCondition(*condition).Build()
+		if err != nil {
+			return err
+		}
+		if err := checkLocalCondition(cond, current); err != nil {
+			return ops.writeError(*def, err)
+		}
+	}
+
+	if index < 0 {
+		l.data[table] = append(l.data[table], itemMap)
+	} else {
+		l.data[table][index] = itemMap
+	}
+	if def.VersionField != "" {
+		b, _ := json.Marshal(map[string]interface{}{def.VersionField: version + 1})
+		return json.Unmarshal(b, values)
+	}
+	return nil
+}
 
-	return fmt.Errorf("item not found")
+func (l *LocalClient) GetOne(table string, partitionKey string, bindTo interface{}) error {
+	return l.GetOneWithContext(context.Background(), table, partitionKey, bindTo)
+}
+
+func (l *LocalClient) GetOneWithContext(ctx context.Context, table string, partitionKey string, bindTo interface{}) error {
+	return l.GetByKey(ctx, table, Key{PartitionKey: partitionKey}, bindTo)
 }
 
 func (l *LocalClient) GetOneWithSort(table string, partitionKey string, sortKey string, bindTo interface{}) error {
-	if l.data[table] == nil {
-		return fmt.Errorf("table %s not found", table)
+	return l.GetOneWithSortWithContext(context.Background(), table, partitionKey, sortKey, bindTo)
+}
+
+func (l *LocalClient) GetOneWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, bindTo interface{}) error {
+	return l.GetByKey(ctx, table, Key{PartitionKey: partitionKey, SortKey: sortKey}, bindTo)
+}
+
+// GetByKey returns the first item matching the partition key and, when given, the
+// sort key of the key.
+func (l *LocalClient) GetByKey(ctx context.Context, table string, key Key, bindTo interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.expire(table)
+	if _, err := l.table(table); err != nil {
+		return err
+	}
+	itemKey, err := l.key(table, key.PartitionKey, key.sortKeys()...)
+	if err != nil {
+		return err
+	}
+	index, itemMap := l.findItem(table, itemKey)
+	if index < 0 {
+		return ErrNotFound
+	}
+	b, err := json.Marshal(itemMap)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, bindTo)
+}
+
+func (l *LocalClient) QueryOne(table string, partitionKey string, limit int32, bindTo interface{}) error {
+	return l.QueryOneWithContext(context.Background(), table, partitionKey, limit, bindTo)
+}
+
+func (l *LocalClient) QueryOneWithContext(ctx context.Context, table string, partitionKey string, limit int32, bindTo interface{}) error {
+	return l.QueryOneByKey(ctx, table, partitionKey, limit, bindTo)
+}
+
+// QueryOneByKey is QueryOne for a partition key of the key type declared on the table.
+func (l *LocalClient) QueryOneByKey(ctx context.Context, table string, partitionKey interface{}, limit int32, bindTo interface{}) error {
+	return l.GetByKey(ctx, table, Key{PartitionKey: partitionKey}, bindTo)
+}
+
+func (l *LocalClient) QueryMultiple(table string, partitionKey interface{}, limit int32, bindTo interface{}) error {
+	return l.QueryMultipleWithContext(context.Background(), table, partitionKey, limit, bindTo)
+}
+
+func (l *LocalClient) QueryMultipleWithContext(ctx context.Context, table string, partitionKey interface{}, limit int32, bindTo interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	if l.tables[table] == nil {
-		return fmt.Errorf("table %s not initialized", table)
+	l.expire(table)
+	if _, err := l.table(table); err != nil {
+		return err
+	}
+	key, err := l.key(table, partitionKey)
+	if err != nil {
+		return err
 	}
 	partitionField := l.tables[table].PartitionKeyField
-	sortField := l.tables[table].SortKeyField
+	var items []interface{}
 	for _, item := range l.data[table] {
 		var itemMap map[string]interface{}
 		inrec, _ := json.Marshal(item)
 		json.Unmarshal(inrec, &itemMap)
-		if itemMap[partitionField] == partitionKey && itemMap[sortField] == sortKey {
-			b, err := json.Marshal(itemMap)
+		if itemMap[partitionField] == key[partitionField] {
+			items = append(items, item)
+		}
+	}
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, bindTo)
+
+}
+
+// QueryExpression returns the items matching the key condition and filter of the
+// query expression in sort key order. pageSize and pageNumber behave as in
+// Implementation.QueryExpression, with pages counted after the filter.
+func (l *LocalClient) QueryExpression(table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	return l.QueryExpressionWithContext(context.Background(), table, query, pageSize, pageNumber, bindTo)
+}
+
+func (l *LocalClient) QueryExpressionWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	return l.QueryGSIWithContext(ctx, table, "", query, pageSize, pageNumber, bindTo)
+}
+
+// QueryGSI is QueryExpression on a secondary index of the table.
+func (l *LocalClient) QueryGSI(table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	return l.QueryGSIWithContext(context.Background(), table, globalIndex, query, pageSize, pageNumber, bindTo)
+}
+
+func (l *LocalClient) QueryGSIWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	_, err := l.QueryGSIResultWithContext(ctx, table, globalIndex, query, pageSize, pageNumber, bindTo)
+	return err
+}
+
+// QueryExpressionResult is QueryExpression returning the count of the page returned
+// and a Cursor that resumes the query after it.
+func (l *LocalClient) QueryExpressionResult(table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	return l.QueryExpressionResultWithContext(context.Background(), table, query, pageSize, pageNumber, bindTo)
+}
+
+func (l *LocalClient) QueryExpressionResultWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	return l.QueryGSIResultWithContext(ctx, table, "", query, pageSize, pageNumber, bindTo)
+}
+
+// QueryGSIResult is QueryExpressionResult on a secondary index of the table.
+func (l *LocalClient) QueryGSIResult(table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	return l.QueryGSIResultWithContext(context.Background(), table, globalIndex, query, pageSize, pageNumber, bindTo)
+}
+
+func (l *LocalClient) QueryGSIResultWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	items, def, err := l.queryItems(ctx, table, globalIndex, query, false)
+	if err != nil {
+		return nil, err
+	}
+	start, end := 0, len(items)
+	limit := int(getLimitPageSize(def.MaxPageSize, pageSize))
+	switch {
+	case limit > 0:
+		if pageNumber > 0 {
+			start = min(int(pageNumber-1)*limit, len(items))
+		}
+		end = min(start+limit, len(items))
+	case pageNumber > 1:
+		// without a limit every item is on the first page
+		start = end
+	}
+	result := &QueryResult{Count: int32(end - start), ScannedCount: int32(end - start), Pages: 1, HasMore: end < len(items)}
+	if result.HasMore {
+		result.Cursor = strconv.Itoa(end)
+	}
+	return result, bindJSON(items[start:end], bindTo)
+}
+
+// QueryExpressionPage returns one page of the items matching the query expression.
+// The cursor of the result is the position of the next item.
+func (l *LocalClient) QueryExpressionPage(table string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	return l.QueryExpressionPageWithContext(context.Background(), table, query, pageSize, cursor, bindTo)
+}
+
+func (l *LocalClient) QueryExpressionPageWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	return l.QueryGSIPageWithContext(ctx, table, "", query, pageSize, cursor, bindTo)
+}
+
+// QueryGSIPage is QueryExpressionPage on a secondary index of the table.
+func (l *LocalClient) QueryGSIPage(table string, globalIndex string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	return l.QueryGSIPageWithContext(context.Background(), table, globalIndex, query, pageSize, cursor, bindTo)
+}
+
+func (l *LocalClient) QueryGSIPageWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	items, def, err := l.queryItems(ctx, table, globalIndex, query, false)
+	if err != nil {
+		return nil, err
+	}
+	return localPage(items, getLimitPageSize(def.MaxPageSize, pageSize), cursor, bindTo)
+}
+
+// QueryKey returns the items of a partition matching the KeyQuery in sort key order.
+// The cursor of the result is the position of the next item.
+func (l *LocalClient) QueryKey(table string, query KeyQuery, bindTo interface{}) (*QueryResult, error) {
+	return l.QueryKeyWithContext(context.Background(), table, query, bindTo)
+}
+
+func (l *LocalClient) QueryKeyWithContext(ctx context.Context, table string, query KeyQuery, bindTo interface{}) (*QueryResult, error) {
+	def, err := l.table(table)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := def.keyExpression(query)
+	if err != nil {
+		return nil, err
+	}
+	items, _, err := l.queryItems(ctx, table, query.Index, expr, query.Descending)
+	if err != nil {
+		return nil, err
+	}
+	return localPage(items, getLimitPageSize(def.MaxPageSize, query.Limit), query.Cursor, bindTo)
+}
+
+// Scan binds the items of the table matching the filter of the expression to bindTo.
+// The local client reads the table as a single segment.
+func (l *LocalClient) Scan(ctx context.Context, table string, filter expression.Expression, bindTo interface{}, opts ...ScanOption) (*QueryResult, error) {
+	items, _, err := l.queryItems(ctx, table, "", filter, false)
+	if err != nil {
+		return nil, err
+	}
+	result := &QueryResult{}
+	result.add(int32(len(items)), int32(len(items)), nil)
+	return result, bindJSON(items, bindTo)
+}
+
+// ScanPages calls fn with the items of the table matching the filter, in pages of
+// the table MaxPageSize items. The local client reads the table as a single segment.
+func (l *LocalClient) ScanPages(ctx context.Context, table string, filter expression.Expression, fn func(page ScanPage) error, opts ...ScanOption) (*QueryResult, error) {
+	items, def, err := l.queryItems(ctx, table, "", filter, false)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := len(items)
+	if def.MaxPageSize > 0 {
+		pageSize = int(def.MaxPageSize)
+	}
+	result := &QueryResult{}
+	for start := 0; start < len(items); start += pageSize {
+		page := ScanPage{}
+		for _, item := range items[start:min(start+pageSize, len(items))] {
+			attributes, err := attributevalue.MarshalMap(item)
 			if err != nil {
-				return err
+				return nil, err
 			}
-			return json.Unmarshal(b, bindTo)
+			page.Items = append(page.Items, attributes)
+		}
+		result.add(int32(len(page.Items)), int32(len(page.Items)), nil)
+		if err := fn(page); err != nil {
+			if errors.Is(err, ErrStopScan) {
+				result.HasMore = start+pageSize < len(items)
+				return result, nil
+			}
+			return nil, err
 		}
 	}
-	return fmt.Errorf("item not found")
+	return result, nil
 }
 
-func (l *LocalClient) QueryOne(table string, partitionKey string, limit int32, bindTo interface{}) error {
-	if l.data[table] == nil {
-		return fmt.Errorf("table %s not found", table)
+// QueryItems iterates over the items matching the query expression in sort key order.
+func (l *LocalClient) QueryItems(ctx context.Context, table string, query expression.Expression) iter.Seq2[Item, error] {
+	return l.QueryGSIItems(ctx, table, "", query)
+}
+
+// QueryGSIItems is QueryItems on a secondary index of the table.
+func (l *LocalClient) QueryGSIItems(ctx context.Context, table string, globalIndex string, query expression.Expression) iter.Seq2[Item, error] {
+	return func(yield func(Item, error) bool) {
+		items, _, err := l.queryItems(ctx, table, globalIndex, query, false)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		yieldLocalItems(items, yield)
 	}
-	if l.tables[table] == nil {
-		return fmt.Errorf("table %s not initialized", table)
+}
+
+// ScanItems iterates over the items of the table matching the filter of the expression.
+func (l *LocalClient) ScanItems(ctx context.Context, table string, filter expression.Expression) iter.Seq2[Item, error] {
+	return l.QueryGSIItems(ctx, table, "", filter)
+}
+
+// yieldLocalItems yields the JSON representation of items as DynamoDB attributes until
+// yield returns false.
+func yieldLocalItems(items []map[string]interface{}, yield func(Item, error) bool) {
+	for _, item := range items {
+		attributes, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if !yield(attributes, nil) {
+			return
+		}
 	}
-	partitionField := l.tables[table].PartitionKeyField
+}
+
+// queryItems returns the items of the table matching the key condition and the
+// filter of the expression, sorted by the sort key of the table or of the named
+// index. Projections are not applied, so whole items are returned.
+func (l *LocalClient) queryItems(ctx context.Context, table string, indexName string, query expression.Expression, descending bool) ([]map[string]interface{}, *DynamoTable, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	l.expire(table)
+	def, err := l.table(table)
+	if err != nil {
+		return nil, nil, err
+	}
+	index, err := def.index(indexName)
+	if err != nil {
+		return nil, nil, err
+	}
+	var items []map[string]interface{}
 	for _, item := range l.data[table] {
 		var itemMap map[string]interface{}
 		inrec, _ := json.Marshal(item)
 		json.Unmarshal(inrec, &itemMap)
-		if itemMap[partitionField] == partitionKey {
-			b, err := json.Marshal(itemMap)
+		ok, err := evalCondition(query.KeyCondition(), query.Names(), query.Values(), itemMap)
+		if err == nil && ok {
+			ok, err = evalCondition(query.Filter(), query.Names(), query.Values(), itemMap)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			items = append(items, itemMap)
+		}
+	}
+	sort.SliceStable(items, func(a, b int) bool {
+		c, _ := compareValues(items[a][index.SortKeyField], items[b][index.SortKeyField])
+		if descending {
+			return c > 0
+		}
+		return c < 0
+	})
+	return items, def, nil
+}
+
+// localPage binds up to limit items starting at the position in cursor, all of them
+// when limit is 0.
+func localPage(items []map[string]interface{}, limit int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	start := 0
+	if cursor != "" {
+		var err error
+		start, err = strconv.Atoi(cursor)
+		if err != nil || start < 0 || start > len(items) {
+			return nil, ErrInvalidCursor
+		}
+	}
+	items = items[start:]
+	result := &QueryResult{Pages: 1}
+	if limit > 0 && int(limit) < len(items) {
+		items = items[:limit]
+		result.HasMore = true
+		result.Cursor = strconv.Itoa(start + int(limit))
+	}
+	result.Count = int32(len(items))
+	result.ScannedCount = result.Count
+	return result, bindJSON(items, bindTo)
+}
+
+// bindJSON decodes the JSON representation of items into bindTo.
+func bindJSON(items interface{}, bindTo interface{}) error {
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, bindTo)
+}
+
+func (l *LocalClient) BatchGetWithSort(request BatchGetRequest, opts ...BatchOption) error {
+	return l.BatchGetWithSortWithContext(context.Background(), request, opts...)
+}
+
+func (l *LocalClient) BatchGetWithSortWithContext(ctx context.Context, request BatchGetRequest, opts ...BatchOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for table, get := range request {
+		def, err := l.table(table)
+		if err != nil {
+			return err
+		}
+		l.expire(table)
+		items := []map[string]interface{}{}
+		found := map[int]bool{}
+		for _, key := range get.Keys {
+			var itemKey map[string]interface{}
+			var err error
+			if def.SortKeyField != "" {
+				itemKey, err = l.key(table, key.PartitionKey, key.SortKey)
+			} else {
+				itemKey, err = l.key(table, key.PartitionKey)
+			}
 			if err != nil {
 				return err
 			}
-			return json.Unmarshal(b, bindTo)
+			if index, item := l.findItem(table, itemKey); item != nil && !found[index] {
+				found[index] = true
+				items = append(items, item)
+			}
+		}
+		b, err := json.Marshal(items)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(b, get.BindTo); err != nil {
+			return err
 		}
 	}
-	return fmt.Errorf("item not found")
+	return nil
 }
 
-func (l *LocalClient) QueryMultiple(table string, partitionKey string, limit int32, bindTo interface{}) error {
-	if l.data[table] == nil {
-		return fmt.Errorf("table %s not found", table)
+func (l *LocalClient) Delete(table string, partitionKey string, opts ...WriteOption) error {
+	return l.DeleteWithContext(context.Background(), table, partitionKey, opts...)
+}
+
+func (l *LocalClient) DeleteWithContext(ctx context.Context, table string, partitionKey string, opts ...WriteOption) error {
+	return l.DeleteByKey(ctx, table, Key{PartitionKey: partitionKey}, opts...)
+}
+
+func (l *LocalClient) DeleteWithSort(table string, partitionKey string, sortKey string, opts ...WriteOption) error {
+	return l.DeleteWithSortWithContext(context.Background(), table, partitionKey, sortKey, opts...)
+}
+
+func (l *LocalClient) DeleteWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, opts ...WriteOption) error {
+	return l.DeleteByKey(ctx, table, Key{PartitionKey: partitionKey, SortKey: sortKey}, opts...)
+}
+
+func (l *LocalClient) DeleteByKey(ctx context.Context, table string, key Key, opts ...WriteOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
-	if l.tables[table] == nil {
-		return fmt.Errorf("table %s not initialized", table)
+	l.expire(table)
+	if _, err := l.table(table); err != nil {
+		return err
 	}
-	partitionField := l.tables[table].PartitionKeyField
-	var items []interface{}
-	for _, item := range l.data[table] {
-		var itemMap map[string]interface{}
-		inrec, _ := json.Marshal(item)
-		json.Unmarshal(inrec, &itemMap)
-		if itemMap[partitionField] == partitionKey {
-			items = append(items, item)
+	itemKey, err := l.key(table, key.PartitionKey, key.sortKeys()...)
+	if err != nil {
+		return err
+	}
+	return l.deleteItem(table, itemKey, opts)
+}
+
+// deleteItem removes the item matching every key attribute. As in DynamoDB, deleting
+// a missing item is not an error unless its old value was requested.
+func (l *LocalClient) deleteItem(table string, key map[string]interface{}, opts []WriteOption) error {
+	ops := newWriteOptions(opts)
+	index, itemMap := l.findItem(table, key)
+
+	def := *l.tables[table]
+	if condition := ops.conditionBuilder(def); condition != nil {
+		cond, err := expression.NewBuilder().WithCondition(*condition).Build()
+		if err != nil {
+			return err
+		}
+		if err := checkLocalCondition(cond, itemMap); err != nil {
+			return ops.writeError(def, err)
 		}
 	}
-	b, err := json.Marshal(items)
+
+	if index < 0 {
+		if ops.oldValue != nil {
+			return ErrNotFound
+		}
+		return nil
+	}
+	l.data[table] = append(l.data[table][:index], l.data[table][index+1:]...)
+
+	if ops.oldValue != nil {
+		b, err := json.Marshal(itemMap)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(b, ops.oldValue)
+	}
+	return nil
+}
+
+func (l *LocalClient) Update(table string, partitionKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	return l.UpdateWithContext(context.Background(), table, partitionKey, update, bindTo, opts...)
+}
+
+func (l *LocalClient) UpdateWithContext(ctx context.Context, table string, partitionKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	return l.UpdateByKey(ctx, table, Key{PartitionKey: partitionKey}, update, bindTo, opts...)
+}
+
+func (l *LocalClient) UpdateWithSort(table string, partitionKey string, sortKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	return l.UpdateWithSortWithContext(context.Background(), table, partitionKey, sortKey, update, bindTo, opts...)
+}
+
+func (l *LocalClient) UpdateWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	return l.UpdateByKey(ctx, table, Key{PartitionKey: partitionKey, SortKey: sortKey}, update, bindTo, opts...)
+}
+
+func (l *LocalClient) UpdateByKey(ctx context.Context, table string, key Key, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.expire(table)
+	if _, err := l.table(table); err != nil {
+		return err
+	}
+	itemKey, err := l.key(table, key.PartitionKey, key.sortKeys()...)
 	if err != nil {
 		return err
 	}
-	return json.Unmarshal(b, bindTo)
+	return l.updateItem(table, itemKey, update, bindTo, opts)
+}
 
+// updateItem applies the update expression to the item matching the key, creating
+// it from the key attributes when it does not exist.
+func (l *LocalClient) updateItem(table string, key map[string]interface{}, update expression.UpdateBuilder, bindTo interface{}, opts []WriteOption) error {
+	ops := newWriteOptions(opts)
+	if _, err := ops.updateReturnValues(bindTo); err != nil {
+		return err
+	}
+	def := *l.tables[table]
+	expr, err := ops.builder(def).WithUpdate(update).Build()
+	if err != nil {
+		return err
+	}
+
+	index, itemMap := l.findItem(table, key)
+	if err := checkLocalCondition(expr, itemMap); err != nil {
+		return ops.writeError(def, err)
+	}
+
+	current := itemMap
+	if index < 0 {
+		current = key
+	}
+	updateExpr, names, values := def.updateActions(expr, l.now())
+	updated, err := applyUpdate(updateExpr, names, values, current)
+	if err != nil {
+		return err
+	}
+	if index < 0 {
+		l.data[table] = append(l.data[table], updated)
+	} else {
+		l.data[table][index] = updated
+	}
+
+	result := updated
+	if bindTo == nil {
+		bindTo = ops.oldValue
+		result = itemMap
+	}
+	if bindTo == nil {
+		return nil
+	}
+	if result == nil {
+		return ErrNotFound
+	}
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, bindTo)
 }
 
-func (l *LocalClient) BatchGetWithSort(values map[string]interface{}) error {
+// checkLocalCondition returns a ConditionalCheckFailedException, as DynamoDB does,
+// when the item does not satisfy the condition of the expression.
+func checkLocalCondition(expr expression.Expression, itemMap map[string]interface{}) error {
+	ok, err := evalCondition(expr.Condition(), expr.Names(), expr.Values(), itemMap)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+	}
 	return nil
 }
+
+// table returns the definition of a registered table, or ErrUnknownTable. A
+// registered table without items is empty.
+func (l *LocalClient) table(name string) (*DynamoTable, error) {
+	def := l.tables[name]
+	if def == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTable, name)
+	}
+	return def, nil
+}
+
+// key validates the key values against the table definition and converts them to
+// their JSON form, which is how stored items are compared.
+func (l *LocalClient) key(table string, partitionKey interface{}, sortKey ...interface{}) (map[string]interface{}, error) {
+	def := l.tables[table]
+	if _, err := def.primaryKey(partitionKey, sortKey...); err != nil {
+		return nil, err
+	}
+	key := map[string]interface{}{def.PartitionKeyField: jsonValue(partitionKey)}
+	if len(sortKey) > 0 {
+		key[def.SortKeyField] = jsonValue(sortKey[0])
+	}
+	return key, nil
+}
+
+// jsonValue returns the value as decoded from JSON, so numbers become float64 and
+// binary values base64 strings.
+func jsonValue(value interface{}) interface{} {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return value
+	}
+	return decoded
+}
+
+// expire deletes the items of the table that are past the time in their TTL
+// attribute. Unlike DynamoDB, which deletes them within days, expired items are
+// removed before every operation on the table.
+func (l *LocalClient) expire(table string) {
+	def := l.tables[table]
+	if def == nil || def.TTLField == "" {
+		return
+	}
+	now := float64(l.now().Unix())
+	live := l.data[table][:0]
+	for _, item := range l.data[table] {
+		var itemMap map[string]interface{}
+		inrec, _ := json.Marshal(item)
+		json.Unmarshal(inrec, &itemMap)
+		if expiresAt, ok := itemMap[def.TTLField].(float64); ok && expiresAt > 0 && expiresAt <= now {
+			continue
+		}
+		live = append(live, item)
+	}
+	l.data[table] = live
+}
+
+// findItem returns the position and JSON representation of the first item matching
+// every key attribute, or -1 when there is none.
+func (l *LocalClient) findItem(table string, key map[string]interface{}) (int, map[string]interface{}) {
+	for index, item := range l.data[table] {
+		var itemMap map[string]interface{}
+		inrec, _ := json.Marshal(item)
+		json.Unmarshal(inrec, &itemMap)
+		matches := true
+		for field, value := range key {
+			if itemMap[field] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return index, itemMap
+		}
+	}
+	return -1, nil
+}
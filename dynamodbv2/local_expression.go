@@ -0,0 +1,574 @@
+package dynamodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// localExpression evaluates the expression strings produced by the expression
+// package against the JSON representation of a LocalClient item. It covers what the
+// local client needs for key conditions, filters and the conditions and updates of
+// its writes: the comparators, BETWEEN, IN, AND, OR, NOT, attribute_exists,
+// attribute_not_exists, begins_with and contains on top level or nested map
+// attributes, and the SET (with +, - and if_not_exists), REMOVE and numeric ADD
+// actions. Anything else is an error.
+type localExpression struct {
+	tokens []string
+	pos    int
+	names  map[string]string
+	values map[string]interface{}
+	item   map[string]interface{}
+}
+
+func newLocalExpression(expr string, names map[string]string, values map[string]types.AttributeValue, item map[string]interface{}) (*localExpression, error) {
+	localValues := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		lv, err := toLocalValue(v)
+		if err != nil {
+			return nil, err
+		}
+		localValues[k] = lv
+	}
+	return &localExpression{
+		tokens: tokenizeExpression(expr),
+		names:  names,
+		values: localValues,
+		item:   item,
+	}, nil
+}
+
+// evalCondition reports whether the item satisfies the condition expression. A nil
+// condition is always satisfied.
+func evalCondition(condition *string, names map[string]string, values map[string]types.AttributeValue, item map[string]interface{}) (bool, error) {
+	if condition == nil || *condition == "" {
+		return true, nil
+	}
+	e, err := newLocalExpression(*condition, names, values, item)
+	if err != nil {
+		return false, err
+	}
+	ok, err := e.parseOr()
+	if err != nil {
+		return false, err
+	}
+	if e.pos != len(e.tokens) {
+		return false, fmt.Errorf("unexpected token %q in expression %q", e.tokens[e.pos], *condition)
+	}
+	return ok, nil
+}
+
+// applyUpdate returns a copy of the item with the SET, REMOVE and ADD actions of the
+// update expression applied. As in DynamoDB, every operand is read from the item as
+// it was before the update.
+func applyUpdate(update *string, names map[string]string, values map[string]types.AttributeValue, item map[string]interface{}) (map[string]interface{}, error) {
+	updated, err := copyItem(item)
+	if err != nil {
+		return nil, err
+	}
+	if update == nil {
+		return updated, nil
+	}
+	e, err := newLocalExpression(*update, names, values, item)
+	if err != nil {
+		return nil, err
+	}
+
+	for e.pos < len(e.tokens) {
+		clause := strings.ToUpper(e.next())
+		for {
+			path, err := e.parsePath()
+			if err != nil {
+				return nil, err
+			}
+			switch clause {
+			case "SET":
+				if err := e.expect("="); err != nil {
+					return nil, err
+				}
+				v, err := e.parseSetValue()
+				if err != nil {
+					return nil, err
+				}
+				if err := setPath(updated, path, v); err != nil {
+					return nil, err
+				}
+			case "REMOVE":
+				if parent, ok := getPath(updated, path[:len(path)-1]).(map[string]interface{}); ok {
+					delete(parent, path[len(path)-1])
+				}
+			case "ADD":
+				v, _, err := e.parseOperand()
+				if err != nil {
+					return nil, err
+				}
+				n, ok := v.(float64)
+				current, exists := lookupPath(updated, path)
+				sum, isNumber := current.(float64)
+				if !ok || (exists && !isNumber) {
+					return nil, fmt.Errorf("unsupported operand type for ADD in the local client")
+				}
+				if err := setPath(updated, path, sum+n); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, fmt.Errorf("unsupported update clause %q in the local client", clause)
+			}
+			if e.peek() != "," {
+				break
+			}
+			e.next()
+		}
+	}
+	return updated, nil
+}
+
+// parseSetValue parses the right hand side of a SET action: an operand, optionally
+// followed by + or - and a second operand.
+func (e *localExpression) parseSetValue() (interface{}, error) {
+	left, err := e.parseSetOperand()
+	if err != nil {
+		return nil, err
+	}
+	op := e.peek()
+	if op != "+" && op != "-" {
+		return left, nil
+	}
+	e.next()
+	right, err := e.parseSetOperand()
+	if err != nil {
+		return nil, err
+	}
+	l, ok1 := left.(float64)
+	r, ok2 := right.(float64)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("incorrect operand type for operator %s", op)
+	}
+	if op == "+" {
+		return l + r, nil
+	}
+	return l - r, nil
+}
+
+// parseSetOperand parses a value placeholder, a path or an if_not_exists call.
+func (e *localExpression) parseSetOperand() (interface{}, error) {
+	if strings.EqualFold(e.peek(), "if_not_exists") {
+		e.next()
+		if err := e.expect("("); err != nil {
+			return nil, err
+		}
+		path, err := e.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		if err := e.expect(","); err != nil {
+			return nil, err
+		}
+		fallback, err := e.parseSetOperand()
+		if err != nil {
+			return nil, err
+		}
+		if err := e.expect(")"); err != nil {
+			return nil, err
+		}
+		if v, exists := lookupPath(e.item, path); exists {
+			return v, nil
+		}
+		return fallback, nil
+	}
+	v, exists, err := e.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("the provided expression refers to an attribute that does not exist in the item")
+	}
+	return v, nil
+}
+
+func setPath(item map[string]interface{}, path []string, v interface{}) error {
+	parent, ok := getPath(item, path[:len(path)-1]).(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("the document path provided in the update expression is invalid for update")
+	}
+	parent[path[len(path)-1]] = v
+	return nil
+}
+
+// copyItem returns a deep copy of the JSON representation of an item.
+func copyItem(item map[string]interface{}) (map[string]interface{}, error) {
+	updated := map[string]interface{}{}
+	if item == nil {
+		return updated, nil
+	}
+	b, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &updated)
+	return updated, err
+}
+
+// toLocalValue converts an attribute value into the value it takes in the JSON
+// representation of an item: strings, float64 numbers, bools, nil, slices and maps.
+func toLocalValue(av types.AttributeValue) (interface{}, error) {
+	var v interface{}
+	if err := attributevalue.Unmarshal(av, &v); err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+func tokenizeExpression(expr string) []string {
+	var tokens []string
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("(),.=+-", r):
+			tokens = append(tokens, string(r))
+			i++
+		case r == '<' || r == '>':
+			if i+1 < len(runes) && (runes[i+1] == '=' || (r == '<' && runes[i+1] == '>')) {
+				tokens = append(tokens, string(runes[i:i+2]))
+				i += 2
+			} else {
+				tokens = append(tokens, string(r))
+				i++
+			}
+		default:
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens
+}
+
+func (e *localExpression) peek() string {
+	if e.pos < len(e.tokens) {
+		return e.tokens[e.pos]
+	}
+	return ""
+}
+
+func (e *localExpression) next() string {
+	t := e.peek()
+	e.pos++
+	return t
+}
+
+func (e *localExpression) expect(token string) error {
+	if t := e.next(); t != token {
+		return fmt.Errorf("expected %q but found %q", token, t)
+	}
+	return nil
+}
+
+func (e *localExpression) keyword(word string) bool {
+	if strings.EqualFold(e.peek(), word) {
+		e.pos++
+		return true
+	}
+	return false
+}
+
+func (e *localExpression) parseOr() (bool, error) {
+	result, err := e.parseAnd()
+	if err != nil {
+		return false, err
+	}
+	for e.keyword("OR") {
+		right, err := e.parseAnd()
+		if err != nil {
+			return false, err
+		}
+		result = result || right
+	}
+	return result, nil
+}
+
+func (e *localExpression) parseAnd() (bool, error) {
+	result, err := e.parseNot()
+	if err != nil {
+		return false, err
+	}
+	for e.keyword("AND") {
+		right, err := e.parseNot()
+		if err != nil {
+			return false, err
+		}
+		result = result && right
+	}
+	return result, nil
+}
+
+func (e *localExpression) parseNot() (bool, error) {
+	if e.keyword("NOT") {
+		result, err := e.parseNot()
+		return !result, err
+	}
+	return e.parsePrimary()
+}
+
+func (e *localExpression) parsePrimary() (bool, error) {
+	if e.peek() == "(" {
+		e.next()
+		result, err := e.parseOr()
+		if err != nil {
+			return false, err
+		}
+		return result, e.expect(")")
+	}
+
+	switch strings.ToLower(e.peek()) {
+	case "attribute_exists", "attribute_not_exists", "begins_with", "contains":
+		return e.parseFunction()
+	}
+
+	left, leftOK, err := e.parseOperand()
+	if err != nil {
+		return false, err
+	}
+	if e.keyword("BETWEEN") {
+		low, lowOK, err := e.parseOperand()
+		if err != nil {
+			return false, err
+		}
+		if !e.keyword("AND") {
+			return false, fmt.Errorf("expected AND in BETWEEN condition")
+		}
+		high, highOK, err := e.parseOperand()
+		if err != nil {
+			return false, err
+		}
+		if !leftOK || !lowOK || !highOK {
+			return false, nil
+		}
+		c1, ok1 := compareValues(left, low)
+		c2, ok2 := compareValues(left, high)
+		return ok1 && ok2 && c1 >= 0 && c2 <= 0, nil
+	}
+	if e.keyword("IN") {
+		if err := e.expect("("); err != nil {
+			return false, err
+		}
+		found := false
+		for {
+			v, ok, err := e.parseOperand()
+			if err != nil {
+				return false, err
+			}
+			if c, comparable := compareValues(left, v); leftOK && ok && comparable && c == 0 {
+				found = true
+			}
+			if e.peek() != "," {
+				break
+			}
+			e.next()
+		}
+		return found, e.expect(")")
+	}
+
+	op := e.next()
+	right, rightOK, err := e.parseOperand()
+	if err != nil {
+		return false, err
+	}
+	if !leftOK || !rightOK {
+		return op == "<>", nil
+	}
+	c, comparable := compareValues(left, right)
+	switch op {
+	case "=":
+		return comparable && c == 0, nil
+	case "<>":
+		return !comparable || c != 0, nil
+	case "<":
+		return comparable && c < 0, nil
+	case "<=":
+		return comparable && c <= 0, nil
+	case ">":
+		return comparable && c > 0, nil
+	case ">=":
+		return comparable && c >= 0, nil
+	}
+	return false, fmt.Errorf("unsupported comparator %q in the local client", op)
+}
+
+func (e *localExpression) parseFunction() (bool, error) {
+	name := strings.ToLower(e.next())
+	if err := e.expect("("); err != nil {
+		return false, err
+	}
+	path, err := e.parsePath()
+	if err != nil {
+		return false, err
+	}
+	value, exists := lookupPath(e.item, path)
+
+	var arg interface{}
+	argOK := false
+	if e.peek() == "," {
+		e.next()
+		if arg, argOK, err = e.parseOperand(); err != nil {
+			return false, err
+		}
+	}
+	if err := e.expect(")"); err != nil {
+		return false, err
+	}
+
+	switch name {
+	case "attribute_exists":
+		return exists, nil
+	case "attribute_not_exists":
+		return !exists, nil
+	case "begins_with":
+		s, ok1 := value.(string)
+		prefix, ok2 := arg.(string)
+		return exists && argOK && ok1 && ok2 && strings.HasPrefix(s, prefix), nil
+	}
+	// contains
+	if !exists || !argOK {
+		return false, nil
+	}
+	switch v := value.(type) {
+	case string:
+		sub, ok := arg.(string)
+		return ok && strings.Contains(v, sub), nil
+	case []interface{}:
+		for _, elem := range v {
+			if c, ok := compareValues(elem, arg); ok && c == 0 {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// parseOperand parses a value placeholder or a path. The boolean result is false
+// when a path does not exist in the item.
+func (e *localExpression) parseOperand() (interface{}, bool, error) {
+	token := e.peek()
+	if strings.HasPrefix(token, ":") {
+		e.next()
+		v, ok := e.values[token]
+		if !ok {
+			return nil, false, fmt.Errorf("missing expression value %s", token)
+		}
+		return v, true, nil
+	}
+	path, err := e.parsePath()
+	if err != nil {
+		return nil, false, err
+	}
+	v, exists := lookupPath(e.item, path)
+	return v, exists, nil
+}
+
+// parsePath parses the attribute names of a document path. List indexes are not
+// supported.
+func (e *localExpression) parsePath() ([]string, error) {
+	var path []string
+	for {
+		token := e.next()
+		name := token
+		if strings.HasPrefix(token, "#") {
+			n, ok := e.names[token]
+			if !ok {
+				return nil, fmt.Errorf("missing expression name %s", token)
+			}
+			name = n
+		}
+		if name == "" || !(unicode.IsLetter([]rune(token)[0]) || token[0] == '#' || token[0] == '_') {
+			return nil, fmt.Errorf("unsupported token %q in the local client", token)
+		}
+		path = append(path, name)
+		switch {
+		case e.peek() == "(":
+			return nil, fmt.Errorf("unsupported function %s in the local client", token)
+		case strings.HasPrefix(e.peek(), "["):
+			return nil, fmt.Errorf("unsupported list index on %s in the local client", name)
+		}
+		if e.peek() != "." {
+			return path, nil
+		}
+		e.next()
+	}
+}
+
+// lookupPath returns the value at the path and whether it exists.
+func lookupPath(item map[string]interface{}, path []string) (interface{}, bool) {
+	var current interface{} = item
+	for _, name := range path {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[name]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// getPath returns the value at the path, or nil when it does not exist.
+func getPath(item map[string]interface{}, path []string) interface{} {
+	v, _ := lookupPath(item, path)
+	return v
+}
+
+// compareValues orders two values of the same type. The boolean result is false
+// when the values cannot be compared.
+func compareValues(a, b interface{}) (int, bool) {
+	switch av := a.(type) {
+	case string:
+		bv, ok := b.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(av, bv), true
+	case float64:
+		bv, ok := b.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case av < bv:
+			return -1, true
+		case av > bv:
+			return 1, true
+		}
+		return 0, true
+	case bool:
+		bv, ok := b.(bool)
+		if !ok || av != bv {
+			return 0, false
+		}
+		return 0, true
+	case nil:
+		return 0, b == nil
+	}
+	ab, err1 := json.Marshal(a)
+	bb, err2 := json.Marshal(b)
+	if err1 != nil || err2 != nil || string(ab) != string(bb) {
+		return 0, false
+	}
+	return 0, true
+}
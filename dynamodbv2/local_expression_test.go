@@ -0,0 +1,243 @@
+package dynamodb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func localTestItem() map[string]interface{} {
+	return map[string]interface{}{
+		"id":      "1",
+		"name":    "John",
+		"age":     float64(30),
+		"active":  true,
+		"nothing": nil,
+		"tags":    []interface{}{"a", "b"},
+		"address": map[string]interface{}{"city": "London"},
+	}
+}
+
+var (
+	localTestNames = map[string]string{
+		"#id": "id", "#name": "name", "#age": "age", "#active": "active", "#nothing": "nothing",
+		"#tags": "tags", "#address": "address", "#city": "city", "#zip": "zip",
+		"#missing": "missing", "#nick": "nick", "#copy": "copy", "#visits": "visits",
+	}
+	localTestValues = map[string]types.AttributeValue{
+		":john":   &types.AttributeValueMemberS{Value: "John"},
+		":peter":  &types.AttributeValueMemberS{Value: "Peter"},
+		":jo":     &types.AttributeValueMemberS{Value: "Jo"},
+		":oh":     &types.AttributeValueMemberS{Value: "oh"},
+		":b":      &types.AttributeValueMemberS{Value: "b"},
+		":z":      &types.AttributeValueMemberS{Value: "z"},
+		":s30":    &types.AttributeValueMemberS{Value: "30"},
+		":london": &types.AttributeValueMemberS{Value: "London"},
+		":paris":  &types.AttributeValueMemberS{Value: "Paris"},
+		":n0":     &types.AttributeValueMemberN{Value: "0"},
+		":n1":     &types.AttributeValueMemberN{Value: "1"},
+		":n2":     &types.AttributeValueMemberN{Value: "2"},
+		":n20":    &types.AttributeValueMemberN{Value: "20"},
+		":n30":    &types.AttributeValueMemberN{Value: "30"},
+		":n31":    &types.AttributeValueMemberN{Value: "31"},
+		":n40":    &types.AttributeValueMemberN{Value: "40"},
+		":true":   &types.AttributeValueMemberBOOL{Value: true},
+		":false":  &types.AttributeValueMemberBOOL{Value: false},
+		":null":   &types.AttributeValueMemberNULL{Value: true},
+		":ss":     &types.AttributeValueMemberSS{Value: []string{"a"}},
+	}
+)
+
+func TestEvalCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition expression.ConditionBuilder
+		want      bool
+	}{
+		{"equal", expression.Name("name").Equal(expression.Value("John")), true},
+		{"between", expression.Name("age").Between(expression.Value(20), expression.Value(30)), true},
+		{"in", expression.Name("name").In(expression.Value("Peter"), expression.Value("John")), true},
+		{"begins with", expression.Name("name").BeginsWith("Jo"), true},
+		{"nested path", expression.Name("address.city").Equal(expression.Value("London")), true},
+		{"and", expression.Name("id").Equal(expression.Value("1")).And(expression.Name("age").GreaterThan(expression.Value(50))), false},
+		{"not", expression.Not(expression.Name("id").Equal(expression.Value("2"))), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := expression.NewBuilder().WithCondition(tt.condition).Build()
+			assert.NoError(t, err)
+
+			got, err := evalCondition(expr.Condition(), expr.Names(), expr.Values(), localTestItem())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEvalConditionOperators(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		// comparators
+		{"#name = :john", true},
+		{"#name <> :john", false},
+		{"#missing = :john", false},
+		{"#missing <> :john", true},
+		{"#age < :n40", true},
+		{"#age <= :n30", true},
+		{"#age > :n30", false},
+		{"#age >= :n31", false},
+		{"#name < :peter", true},
+		{"#age = :s30", false},
+		{"#age <> :s30", true},
+		{"#active = :true", true},
+		{"#active <> :false", true},
+		{"#nothing = :null", true},
+		// BETWEEN and IN
+		{"#age BETWEEN :n20 AND :n30", true},
+		{"#age BETWEEN :n31 AND :n40", false},
+		{"#age BETWEEN :s30 AND :n40", false},
+		{"#missing BETWEEN :n20 AND :n30", false},
+		{"#name IN (:peter, :john)", true},
+		{"#name IN (:peter)", false},
+		// functions
+		{"attribute_exists(#name)", true},
+		{"attribute_not_exists(#missing)", true},
+		{"attribute_exists(#address.#city)", true},
+		{"attribute_exists(#address.#zip)", false},
+		{"begins_with(#name, :jo)", true},
+		{"begins_with(#name, :oh)", false},
+		{"begins_with(#age, :s30)", false},
+		{"contains(#name, :oh)", true},
+		{"contains(#tags, :b)", true},
+		{"contains(#tags, :z)", false},
+		{"contains(#missing, :b)", false},
+		// nested paths
+		{"#address.#city = :london", true},
+		{"#name.#city = :london", false},
+		// logical operators, precedence and keyword case
+		{"#name = :peter OR #age = :n30", true},
+		{"NOT NOT #name = :peter", false},
+		{"#name = :john OR #name = :peter AND #age = :n40", true},
+		{"(#name = :john OR #name = :peter) AND #age = :n40", false},
+		{"#age between :n20 and :n30 and not #active = :false", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			got, err := evalCondition(aws.String(tt.condition), localTestNames, localTestValues, localTestItem())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	got, err := evalCondition(nil, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.True(t, got)
+}
+
+func TestEvalConditionErrors(t *testing.T) {
+	tests := []struct {
+		condition string
+		err       string
+	}{
+		{"#unknown = :john", "missing expression name #unknown"},
+		{"#name = :unknown", "missing expression value :unknown"},
+		{"#name = :john #age", `unexpected token "#age"`},
+		{"#name ~ :john", `unsupported comparator "~"`},
+		{"#age BETWEEN :n20 :n30", "expected AND in BETWEEN condition"},
+		{"#name IN (:john", `expected ")"`},
+		{"(#name = :john", `expected ")"`},
+		{"size(#tags) = :n2", "unsupported function size"},
+		{"attribute_type(#age, :n1)", "unsupported function attribute_type"},
+		{"#tags[0] = :b", "unsupported list index on tags"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.condition, func(t *testing.T) {
+			_, err := evalCondition(aws.String(tt.condition), localTestNames, localTestValues, localTestItem())
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestApplyUpdate(t *testing.T) {
+	tests := []struct {
+		update string
+		want   map[string]interface{}
+	}{
+		// SET
+		{"SET #name = :peter", map[string]interface{}{"name": "Peter"}},
+		{"set #name = :peter", map[string]interface{}{"name": "Peter"}},
+		{"SET #age = #age + :n1", map[string]interface{}{"age": float64(31)}},
+		{"SET #age = #age - :n1", map[string]interface{}{"age": float64(29)}},
+		{"SET #name = :peter, #age = :n40", map[string]interface{}{"name": "Peter", "age": float64(40)}},
+		{"SET #age = :n1, #copy = #age", map[string]interface{}{"age": float64(1), "copy": float64(30)}},
+		{"SET #nick = if_not_exists(#nick, :peter)", map[string]interface{}{"nick": "Peter"}},
+		{"SET #name = if_not_exists(#name, :peter)", map[string]interface{}{}},
+		{"SET #visits = if_not_exists(#visits, :n0) + :n1", map[string]interface{}{"visits": float64(1)}},
+		{"SET #address.#city = :paris", map[string]interface{}{"address": map[string]interface{}{"city": "Paris"}}},
+		// REMOVE
+		{"REMOVE #name, #age", map[string]interface{}{"name": nil, "age": nil}},
+		{"REMOVE #address.#city", map[string]interface{}{"address": map[string]interface{}{}}},
+		{"REMOVE #missing.#city", map[string]interface{}{}},
+		// ADD
+		{"ADD #age :n2", map[string]interface{}{"age": float64(32)}},
+		{"ADD #visits :n1", map[string]interface{}{"visits": float64(1)}},
+		// several clauses
+		{"SET #name = :peter REMOVE #age ADD #visits :n1", map[string]interface{}{"name": "Peter", "age": nil, "visits": float64(1)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.update, func(t *testing.T) {
+			item := localTestItem()
+			got, err := applyUpdate(aws.String(tt.update), localTestNames, localTestValues, item)
+			assert.NoError(t, err)
+
+			want := localTestItem()
+			for k, v := range tt.want {
+				if v == nil {
+					delete(want, k)
+				} else {
+					want[k] = v
+				}
+			}
+			assert.Equal(t, want, got)
+			assert.Equal(t, localTestItem(), item)
+		})
+	}
+
+	got, err := applyUpdate(nil, nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{}, got)
+}
+
+func TestApplyUpdateErrors(t *testing.T) {
+	tests := []struct {
+		update string
+		err    string
+	}{
+		{"SET #name = #name + :n1", "incorrect operand type for operator +"},
+		{"SET #nick = #missing", "refers to an attribute that does not exist"},
+		{"SET #missing.#city = :paris", "document path provided in the update expression is invalid"},
+		{"SET #name :peter", `expected "="`},
+		{"SET #nick = if_not_exists(#nick :peter)", `expected ","`},
+		{"ADD #name :n1", "unsupported operand type for ADD"},
+		{"ADD #tags :ss", "unsupported operand type for ADD"},
+		{"DELETE #tags :ss", `unsupported update clause "DELETE"`},
+		{"SET #tags = list_append(#tags, :ss)", "unsupported function list_append"},
+		{"SET #unknown = :peter", "missing expression name #unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.update, func(t *testing.T) {
+			_, err := applyUpdate(aws.String(tt.update), localTestNames, localTestValues, localTestItem())
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
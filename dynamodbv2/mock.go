@@ -1,6 +1,9 @@
 package dynamodb
 
 import (
+	"context"
+	"iter"
+
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
 	"github.com/stretchr/testify/mock"
 )
@@ -9,7 +12,7 @@ type DynamoMock struct {
 	mock.Mock
 }
 
-func (mock *DynamoMock) Save(table string, item interface{}) error {
+func (mock *DynamoMock) Save(table string, item interface{}, opts ...WriteOption) error {
 	args := mock.Called(item)
 	return args.Error(0)
 }
@@ -29,12 +32,12 @@ func (mock *DynamoMock) QueryOne(table string, partitionKey string, limit int32,
 	return args.Error(0)
 }
 
-func (mock *DynamoMock) BatchGetWithSort(values map[string]interface{}) error {
-	ret := mock.Called(values)
+func (mock *DynamoMock) BatchGetWithSort(request BatchGetRequest, opts ...BatchOption) error {
+	ret := mock.Called(request)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(map[string]interface{}) error); ok {
-		r0 = rf(values)
+	if rf, ok := ret.Get(0).(func(BatchGetRequest) error); ok {
+		r0 = rf(request)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -83,3 +86,727 @@ func (_m *DynamoMock) QueryGSI(table string, globalIndex string, query expressio
 
 	return r0
 }
+
+// SaveWithContext provides a mock function with given fields: ctx, table, item, opts
+func (_m *DynamoMock) SaveWithContext(ctx context.Context, table string, item interface{}, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, table, item)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, ...WriteOption) error); ok {
+		r0 = rf(ctx, table, item, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetOneWithContext provides a mock function with given fields: ctx, table, partitionKey, bindTo
+func (_m *DynamoMock) GetOneWithContext(ctx context.Context, table string, partitionKey string, bindTo interface{}) error {
+	ret := _m.Called(ctx, table, partitionKey, bindTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, interface{}) error); ok {
+		r0 = rf(ctx, table, partitionKey, bindTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetOneWithSortWithContext provides a mock function with given fields: ctx, table, partitionKey, sortKey, bindTo
+func (_m *DynamoMock) GetOneWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, bindTo interface{}) error {
+	ret := _m.Called(ctx, table, partitionKey, sortKey, bindTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, interface{}) error); ok {
+		r0 = rf(ctx, table, partitionKey, sortKey, bindTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// QueryOneWithContext provides a mock function with given fields: ctx, table, partitionKey, limit, bindTo
+func (_m *DynamoMock) QueryOneWithContext(ctx context.Context, table string, partitionKey string, limit int32, bindTo interface{}) error {
+	ret := _m.Called(ctx, table, partitionKey, limit, bindTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int32, interface{}) error); ok {
+		r0 = rf(ctx, table, partitionKey, limit, bindTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// BatchGetWithSortWithContext provides a mock function with given fields: ctx, request, opts
+func (_m *DynamoMock) BatchGetWithSortWithContext(ctx context.Context, request BatchGetRequest, opts ...BatchOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, request)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, BatchGetRequest, ...BatchOption) error); ok {
+		r0 = rf(ctx, request, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// QueryExpressionWithContext provides a mock function with given fields: ctx, table, query, pageSize, pageNumber, bindTo
+func (_m *DynamoMock) QueryExpressionWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error {
+	ret := _m.Called(ctx, table, query, pageSize, pageNumber, bindTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression, int32, int32, interface{}) error); ok {
+		r0 = rf(ctx, table, query, pageSize, pageNumber, bindTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// QueryGSIWithContext provides a mock function with given fields: ctx, table, globalIndex, query, customLimit, pageDesired, bindTo
+func (_m *DynamoMock) QueryGSIWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, customLimit int32, pageDesired int32, bindTo interface{}) error {
+	ret := _m.Called(ctx, table, globalIndex, query, customLimit, pageDesired, bindTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, expression.Expression, int32, int32, interface{}) error); ok {
+		r0 = rf(ctx, table, globalIndex, query, customLimit, pageDesired, bindTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: table, partitionKey, opts
+func (_m *DynamoMock) Delete(table string, partitionKey string, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, table, partitionKey)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, ...WriteOption) error); ok {
+		r0 = rf(table, partitionKey, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWithSort provides a mock function with given fields: table, partitionKey, sortKey, opts
+func (_m *DynamoMock) DeleteWithSort(table string, partitionKey string, sortKey string, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, table, partitionKey, sortKey)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, ...WriteOption) error); ok {
+		r0 = rf(table, partitionKey, sortKey, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWithContext provides a mock function with given fields: ctx, table, partitionKey, opts
+func (_m *DynamoMock) DeleteWithContext(ctx context.Context, table string, partitionKey string, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, table, partitionKey)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, ...WriteOption) error); ok {
+		r0 = rf(ctx, table, partitionKey, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteWithSortWithContext provides a mock function with given fields: ctx, table, partitionKey, sortKey, opts
+func (_m *DynamoMock) DeleteWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, table, partitionKey, sortKey)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, ...WriteOption) error); ok {
+		r0 = rf(ctx, table, partitionKey, sortKey, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Update provides a mock function with given fields: table, partitionKey, update, bindTo, opts
+func (_m *DynamoMock) Update(table string, partitionKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, table, partitionKey, update, bindTo)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, expression.UpdateBuilder, interface{}, ...WriteOption) error); ok {
+		r0 = rf(table, partitionKey, update, bindTo, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWithSort provides a mock function with given fields: table, partitionKey, sortKey, update, bindTo, opts
+func (_m *DynamoMock) UpdateWithSort(table string, partitionKey string, sortKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, table, partitionKey, sortKey, update, bindTo)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, expression.UpdateBuilder, interface{}, ...WriteOption) error); ok {
+		r0 = rf(table, partitionKey, sortKey, update, bindTo, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWithContext provides a mock function with given fields: ctx, table, partitionKey, update, bindTo, opts
+func (_m *DynamoMock) UpdateWithContext(ctx context.Context, table string, partitionKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, table, partitionKey, update, bindTo)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, expression.UpdateBuilder, interface{}, ...WriteOption) error); ok {
+		r0 = rf(ctx, table, partitionKey, update, bindTo, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateWithSortWithContext provides a mock function with given fields: ctx, table, partitionKey, sortKey, update, bindTo, opts
+func (_m *DynamoMock) UpdateWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, table, partitionKey, sortKey, update, bindTo)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, expression.UpdateBuilder, interface{}, ...WriteOption) error); ok {
+		r0 = rf(ctx, table, partitionKey, sortKey, update, bindTo, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// QueryExpressionPage provides a mock function with given fields: table, query, pageSize, cursor, bindTo
+func (_m *DynamoMock) QueryExpressionPage(table string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(table, query, pageSize, cursor, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, expression.Expression, int32, string, interface{}) (*QueryResult, error)); ok {
+		return rf(table, query, pageSize, cursor, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(string, expression.Expression, int32, string, interface{}) *QueryResult); ok {
+		r0 = rf(table, query, pageSize, cursor, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, expression.Expression, int32, string, interface{}) error); ok {
+		r1 = rf(table, query, pageSize, cursor, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryGSIPage provides a mock function with given fields: table, globalIndex, query, pageSize, cursor, bindTo
+func (_m *DynamoMock) QueryGSIPage(table string, globalIndex string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(table, globalIndex, query, pageSize, cursor, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, expression.Expression, int32, string, interface{}) (*QueryResult, error)); ok {
+		return rf(table, globalIndex, query, pageSize, cursor, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, expression.Expression, int32, string, interface{}) *QueryResult); ok {
+		r0 = rf(table, globalIndex, query, pageSize, cursor, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, expression.Expression, int32, string, interface{}) error); ok {
+		r1 = rf(table, globalIndex, query, pageSize, cursor, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryExpressionPageWithContext provides a mock function with given fields: ctx, table, query, pageSize, cursor, bindTo
+func (_m *DynamoMock) QueryExpressionPageWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(ctx, table, query, pageSize, cursor, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression, int32, string, interface{}) (*QueryResult, error)); ok {
+		return rf(ctx, table, query, pageSize, cursor, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression, int32, string, interface{}) *QueryResult); ok {
+		r0 = rf(ctx, table, query, pageSize, cursor, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, expression.Expression, int32, string, interface{}) error); ok {
+		r1 = rf(ctx, table, query, pageSize, cursor, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryGSIPageWithContext provides a mock function with given fields: ctx, table, globalIndex, query, pageSize, cursor, bindTo
+func (_m *DynamoMock) QueryGSIPageWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(ctx, table, globalIndex, query, pageSize, cursor, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, expression.Expression, int32, string, interface{}) (*QueryResult, error)); ok {
+		return rf(ctx, table, globalIndex, query, pageSize, cursor, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, expression.Expression, int32, string, interface{}) *QueryResult); ok {
+		r0 = rf(ctx, table, globalIndex, query, pageSize, cursor, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, expression.Expression, int32, string, interface{}) error); ok {
+		r1 = rf(ctx, table, globalIndex, query, pageSize, cursor, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryKey provides a mock function with given fields: table, query, bindTo
+func (_m *DynamoMock) QueryKey(table string, query KeyQuery, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(table, query, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, KeyQuery, interface{}) (*QueryResult, error)); ok {
+		return rf(table, query, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(string, KeyQuery, interface{}) *QueryResult); ok {
+		r0 = rf(table, query, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, KeyQuery, interface{}) error); ok {
+		r1 = rf(table, query, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryKeyWithContext provides a mock function with given fields: ctx, table, query, bindTo
+func (_m *DynamoMock) QueryKeyWithContext(ctx context.Context, table string, query KeyQuery, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(ctx, table, query, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, KeyQuery, interface{}) (*QueryResult, error)); ok {
+		return rf(ctx, table, query, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, KeyQuery, interface{}) *QueryResult); ok {
+		r0 = rf(ctx, table, query, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, KeyQuery, interface{}) error); ok {
+		r1 = rf(ctx, table, query, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Scan provides a mock function with given fields: ctx, table, filter, bindTo, opts
+func (_m *DynamoMock) Scan(ctx context.Context, table string, filter expression.Expression, bindTo interface{}, opts ...ScanOption) (*QueryResult, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, table, filter, bindTo)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression, interface{}, ...ScanOption) (*QueryResult, error)); ok {
+		return rf(ctx, table, filter, bindTo, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression, interface{}, ...ScanOption) *QueryResult); ok {
+		r0 = rf(ctx, table, filter, bindTo, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, expression.Expression, interface{}, ...ScanOption) error); ok {
+		r1 = rf(ctx, table, filter, bindTo, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ScanPages provides a mock function with given fields: ctx, table, filter, fn, opts
+func (_m *DynamoMock) ScanPages(ctx context.Context, table string, filter expression.Expression, fn func(page ScanPage) error, opts ...ScanOption) (*QueryResult, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, table, filter, fn)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression, func(ScanPage) error, ...ScanOption) (*QueryResult, error)); ok {
+		return rf(ctx, table, filter, fn, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression, func(ScanPage) error, ...ScanOption) *QueryResult); ok {
+		r0 = rf(ctx, table, filter, fn, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, expression.Expression, func(ScanPage) error, ...ScanOption) error); ok {
+		r1 = rf(ctx, table, filter, fn, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryGSIItems provides a mock function with given fields: ctx, table, globalIndex, query
+func (_m *DynamoMock) QueryGSIItems(ctx context.Context, table string, globalIndex string, query expression.Expression) iter.Seq2[Item, error] {
+	ret := _m.Called(ctx, table, globalIndex, query)
+
+	var r0 iter.Seq2[Item, error]
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, expression.Expression) iter.Seq2[Item, error]); ok {
+		r0 = rf(ctx, table, globalIndex, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(iter.Seq2[Item, error])
+		}
+	}
+
+	return r0
+}
+
+// QueryItems provides a mock function with given fields: ctx, table, query
+func (_m *DynamoMock) QueryItems(ctx context.Context, table string, query expression.Expression) iter.Seq2[Item, error] {
+	ret := _m.Called(ctx, table, query)
+
+	var r0 iter.Seq2[Item, error]
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression) iter.Seq2[Item, error]); ok {
+		r0 = rf(ctx, table, query)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(iter.Seq2[Item, error])
+		}
+	}
+
+	return r0
+}
+
+// ScanItems provides a mock function with given fields: ctx, table, filter
+func (_m *DynamoMock) ScanItems(ctx context.Context, table string, filter expression.Expression) iter.Seq2[Item, error] {
+	ret := _m.Called(ctx, table, filter)
+
+	var r0 iter.Seq2[Item, error]
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression) iter.Seq2[Item, error]); ok {
+		r0 = rf(ctx, table, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(iter.Seq2[Item, error])
+		}
+	}
+
+	return r0
+}
+
+// QueryExpressionResult provides a mock function with given fields: table, query, pageSize, pageNumber, bindTo
+func (_m *DynamoMock) QueryExpressionResult(table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(table, query, pageSize, pageNumber, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, expression.Expression, int32, int32, interface{}) (*QueryResult, error)); ok {
+		return rf(table, query, pageSize, pageNumber, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(string, expression.Expression, int32, int32, interface{}) *QueryResult); ok {
+		r0 = rf(table, query, pageSize, pageNumber, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, expression.Expression, int32, int32, interface{}) error); ok {
+		r1 = rf(table, query, pageSize, pageNumber, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryExpressionResultWithContext provides a mock function with given fields: ctx, table, query, pageSize, pageNumber, bindTo
+func (_m *DynamoMock) QueryExpressionResultWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(ctx, table, query, pageSize, pageNumber, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression, int32, int32, interface{}) (*QueryResult, error)); ok {
+		return rf(ctx, table, query, pageSize, pageNumber, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, expression.Expression, int32, int32, interface{}) *QueryResult); ok {
+		r0 = rf(ctx, table, query, pageSize, pageNumber, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, expression.Expression, int32, int32, interface{}) error); ok {
+		r1 = rf(ctx, table, query, pageSize, pageNumber, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryGSIResult provides a mock function with given fields: table, globalIndex, query, customLimit, pageDesired, bindTo
+func (_m *DynamoMock) QueryGSIResult(table string, globalIndex string, query expression.Expression, customLimit int32, pageDesired int32, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(table, globalIndex, query, customLimit, pageDesired, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string, expression.Expression, int32, int32, interface{}) (*QueryResult, error)); ok {
+		return rf(table, globalIndex, query, customLimit, pageDesired, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(string, string, expression.Expression, int32, int32, interface{}) *QueryResult); ok {
+		r0 = rf(table, globalIndex, query, customLimit, pageDesired, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string, expression.Expression, int32, int32, interface{}) error); ok {
+		r1 = rf(table, globalIndex, query, customLimit, pageDesired, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// QueryGSIResultWithContext provides a mock function with given fields: ctx, table, globalIndex, query, customLimit, pageDesired, bindTo
+func (_m *DynamoMock) QueryGSIResultWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, customLimit int32, pageDesired int32, bindTo interface{}) (*QueryResult, error) {
+	ret := _m.Called(ctx, table, globalIndex, query, customLimit, pageDesired, bindTo)
+
+	var r0 *QueryResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, expression.Expression, int32, int32, interface{}) (*QueryResult, error)); ok {
+		return rf(ctx, table, globalIndex, query, customLimit, pageDesired, bindTo)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, expression.Expression, int32, int32, interface{}) *QueryResult); ok {
+		r0 = rf(ctx, table, globalIndex, query, customLimit, pageDesired, bindTo)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*QueryResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, expression.Expression, int32, int32, interface{}) error); ok {
+		r1 = rf(ctx, table, globalIndex, query, customLimit, pageDesired, bindTo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetByKey provides a mock function with given fields: ctx, table, key, bindTo
+func (_m *DynamoMock) GetByKey(ctx context.Context, table string, key Key, bindTo interface{}) error {
+	ret := _m.Called(ctx, table, key, bindTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, Key, interface{}) error); ok {
+		r0 = rf(ctx, table, key, bindTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// QueryOneByKey provides a mock function with given fields: ctx, table, partitionKey, limit, bindTo
+func (_m *DynamoMock) QueryOneByKey(ctx context.Context, table string, partitionKey interface{}, limit int32, bindTo interface{}) error {
+	ret := _m.Called(ctx, table, partitionKey, limit, bindTo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, int32, interface{}) error); ok {
+		r0 = rf(ctx, table, partitionKey, limit, bindTo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteByKey provides a mock function with given fields: ctx, table, key, opts
+func (_m *DynamoMock) DeleteByKey(ctx context.Context, table string, key Key, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, table, key)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, Key, ...WriteOption) error); ok {
+		r0 = rf(ctx, table, key, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateByKey provides a mock function with given fields: ctx, table, key, update, bindTo, opts
+func (_m *DynamoMock) UpdateByKey(ctx context.Context, table string, key Key, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, table, key, update, bindTo)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, Key, expression.UpdateBuilder, interface{}, ...WriteOption) error); ok {
+		r0 = rf(ctx, table, key, update, bindTo, opts...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
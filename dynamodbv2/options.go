@@ -0,0 +1,129 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// WriteOption customizes a write operation such as Save, Delete or Update.
+type WriteOption func(o *writeOptions)
+
+type writeOptions struct {
+	condition  *expression.ConditionBuilder
+	oldValue   interface{}
+	version    *int64
+	createOnly bool
+	expiresAt  *time.Time
+}
+
+// WithCondition only applies the write when the item satisfies the condition.
+// A failed condition returns an error matching ErrConditionFailed.
+func WithCondition(condition expression.ConditionBuilder) WriteOption {
+	return func(o *writeOptions) {
+		o.condition = &condition
+	}
+}
+
+// IfNotExists only applies a Save or Update when no item with the same key exists,
+// so an existing item is never overwritten.
+func IfNotExists() WriteOption {
+	return func(o *writeOptions) {
+		o.createOnly = true
+	}
+}
+
+// WithOldValue unmarshals the item as it was before the write into bindTo.
+// ErrNotFound is returned when there was no previous item.
+func WithOldValue(bindTo interface{}) WriteOption {
+	return func(o *writeOptions) {
+		o.oldValue = bindTo
+	}
+}
+
+// WithVersion guards an Update or Delete on a versioned table: the write fails with
+// ErrVersionConflict unless the stored item is still at the given version. Without it
+// they are unguarded, and Update increments the version whatever it is.
+func WithVersion(version int64) WriteOption {
+	return func(o *writeOptions) {
+		o.version = &version
+	}
+}
+
+func newWriteOptions(opts []WriteOption) *writeOptions {
+	o := &writeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// conditionBuilder combines the condition of the options with the create-only check
+// and, on versioned tables, the version guard. It returns nil when the write is
+// unconditional.
+func (o *writeOptions) conditionBuilder(table DynamoTable) *expression.ConditionBuilder {
+	var conditions []expression.ConditionBuilder
+	if o.guarded(table) {
+		conditions = append(conditions, versionCondition(table.VersionField, *o.version))
+	}
+	if o.createOnly {
+		conditions = append(conditions, expression.AttributeNotExists(expression.Name(table.PartitionKeyField)))
+	}
+	if o.condition != nil {
+		conditions = append(conditions, *o.condition)
+	}
+
+	switch len(conditions) {
+	case 0:
+		return nil
+	case 1:
+		return &conditions[0]
+	}
+	condition := conditions[0].And(conditions[1], conditions[2:]...)
+	return &condition
+}
+
+// builder returns an expression builder holding the condition of the options, if any.
+func (o *writeOptions) builder(table DynamoTable) expression.Builder {
+	builder := expression.NewBuilder()
+	if condition := o.conditionBuilder(table); condition != nil {
+		builder = builder.WithCondition(*condition)
+	}
+	return builder
+}
+
+// guarded reports whether the write is guarded by a version condition.
+func (o *writeOptions) guarded(table DynamoTable) bool {
+	return table.VersionField != "" && o.version != nil
+}
+
+// writeError maps a failed condition check to ErrVersionConflict when the write was
+// guarded by a version, or to ErrConditionFailed otherwise. The SDK error stays
+// available through errors.As.
+func (o *writeOptions) writeError(table DynamoTable, err error) error {
+	var conditionErr *types.ConditionalCheckFailedException
+	if !errors.As(err, &conditionErr) {
+		return err
+	}
+	if o.guarded(table) {
+		return fmt.Errorf("%w: %w: %w", ErrVersionConflict, ErrConditionFailed, err)
+	}
+	return fmt.Errorf("%w: %w", ErrConditionFailed, err)
+}
+
+// updateReturnValues selects what UpdateItem returns: the new item when bindTo is
+// set, or the old one when requested with WithOldValue.
+func (o *writeOptions) updateReturnValues(bindTo interface{}) (types.ReturnValue, error) {
+	switch {
+	case bindTo != nil && o.oldValue != nil:
+		return types.ReturnValueNone, errors.New("dynamo: update can return either the new or the old item, not both")
+	case bindTo != nil:
+		return types.ReturnValueAllNew, nil
+	case o.oldValue != nil:
+		return types.ReturnValueAllOld, nil
+	}
+	return types.ReturnValueNone, nil
+}
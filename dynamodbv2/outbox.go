@@ -0,0 +1,324 @@
+package dynamodb
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/abraham-corales/go-aws/sns"
+	"github.com/abraham-corales/go-aws/sqs"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// Outbox record states. Only pending records are in the pending index of an outbox
+// table; published records have no status.
+const (
+	outboxPending = "pending"
+	outboxFailed  = "failed"
+
+	outboxPendingIndex = "pending"
+	outboxStatusField  = "outbox_status"
+)
+
+// OutboxTable returns the definition of an outbox table, to be registered on the
+// client and created with ProvisionTables. Its pending index lists the records that
+// are not published yet in the order they were written.
+func OutboxTable(name string) DynamoTable {
+	return DynamoTable{
+		TableName:         name,
+		PartitionKeyField: "id",
+		GlobalIndexes: []Index{{
+			Name:              outboxPendingIndex,
+			PartitionKeyField: outboxStatusField,
+			SortKeyField:      "created_at",
+			SortKeyType:       KeyTypeNumber,
+		}},
+	}
+}
+
+// Message is an event written to an outbox table. Payload is marshalled to JSON and
+// becomes the body of the published message. GroupID is the message group of FIFO
+// topics and queues.
+type Message struct {
+	Type       string
+	Payload    interface{}
+	GroupID    string
+	Attributes map[string]string
+}
+
+// OutboxMessage is a message stored in an outbox table, as passed to a Publisher.
+// CreatedAt is in epoch nanoseconds.
+type OutboxMessage struct {
+	ID         string            `dynamo:"id"`
+	Type       string            `dynamo:"type"`
+	Payload    string            `dynamo:"payload"`
+	GroupID    string            `dynamo:"group_id,omitempty"`
+	Attributes map[string]string `dynamo:"attributes,omitempty"`
+	Status     string            `dynamo:"outbox_status,omitempty"`
+	CreatedAt  int64             `dynamo:"created_at"`
+	Attempts   int               `dynamo:"attempts"`
+	LastError  string            `dynamo:"last_error,omitempty"`
+}
+
+// Publish adds the message to the outbox table in the transaction, so that it is
+// stored if and only if the other writes of the transaction are.
+//
+//	err := client.WriteTransaction().
+//		Save("orders", order, dynamodb.IfNotExists()).
+//		Publish("outbox", dynamodb.Message{Type: "OrderCreated", Payload: order}).
+//		Commit(ctx)
+func (t *WriteTransaction) Publish(outboxTable string, message Message) *WriteTransaction {
+	if t.err != nil {
+		return t
+	}
+	payload, err := json.Marshal(message.Payload)
+	if err != nil {
+		t.err = fmt.Errorf("failed to marshal message %s: %w", message.Type, err)
+		return t
+	}
+	return t.Save(outboxTable, OutboxMessage{
+		ID:         newMessageID(),
+		Type:       message.Type,
+		Payload:    string(payload),
+		GroupID:    message.GroupID,
+		Attributes: message.Attributes,
+		Status:     outboxPending,
+		CreatedAt:  t.client.now().UnixNano(),
+	}, IfNotExists())
+}
+
+// SaveAndPublish saves the item and adds the message to the outbox table in one
+// transaction.
+func (i *Implementation) SaveAndPublish(ctx context.Context, table string, values interface{}, outboxTable string, message Message, opts ...WriteOption) error {
+	return i.WriteTransaction().
+		Save(table, values, opts...).
+		Publish(outboxTable, message).
+		Commit(ctx)
+}
+
+func newMessageID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Publisher sends the messages drained from an outbox table. It is called again with
+// the same message when it fails, so the ID should be used to drop duplicates.
+type Publisher interface {
+	Publish(ctx context.Context, message OutboxMessage) error
+}
+
+// PublisherFunc adapts a function to a Publisher.
+type PublisherFunc func(ctx context.Context, message OutboxMessage) error
+
+func (f PublisherFunc) Publish(ctx context.Context, message OutboxMessage) error {
+	return f(ctx, message)
+}
+
+// NewSNSPublisher returns a Publisher that publishes to an SNS topic with the
+// sns.Publisher of this module. The payload is the message body, and the type and ID
+// are sent in the type and id message attributes next to the message attributes.
+// Messages with a GroupID use the ID as deduplication id. sns.Publisher takes no
+// context, so a publish in flight is not cancelled with ctx.
+func NewSNSPublisher(publisher sns.Publisher) Publisher {
+	return PublisherFunc(func(ctx context.Context, message OutboxMessage) error {
+		ops := sns.Options{MessageAttributes: messageAttributes(message)}
+		if message.GroupID != "" {
+			ops.MessageGroupID = aws.String(message.GroupID)
+			ops.DeduplicationID = aws.String(message.ID)
+		}
+		return publisher.PublishWithOptions(json.RawMessage(message.Payload), ops)
+	})
+}
+
+// NewSQSPublisher returns a Publisher that sends to an SQS queue with the sqs.Spec of
+// this module, with the same body and attributes as NewSNSPublisher.
+func NewSQSPublisher(queue sqs.Spec) Publisher {
+	return PublisherFunc(func(ctx context.Context, message OutboxMessage) error {
+		ops := sqs.Options{MessageAttributes: messageAttributes(message)}
+		if message.GroupID != "" {
+			ops.MessageGroupID = aws.String(message.GroupID)
+			ops.DeduplicationID = aws.String(message.ID)
+		}
+		_, err := queue.SendMessageWithOptions(json.RawMessage(message.Payload), ops)
+		return err
+	})
+}
+
+// messageAttributes returns the message attributes with the type and id added.
+func messageAttributes(message OutboxMessage) map[string]any {
+	attributes := map[string]any{"type": message.Type, "id": message.ID}
+	for name, value := range message.Attributes {
+		attributes[name] = value
+	}
+	return attributes
+}
+
+// RelayOption customizes an OutboxRelay.
+type RelayOption func(o *relayOptions)
+
+type relayOptions struct {
+	pollInterval time.Duration
+	retries      int
+	backoff      time.Duration
+	maxAttempts  int
+	pageSize     int32
+}
+
+// WithRelayPollInterval sets how often Run drains the outbox. Defaults to 1 second.
+func WithRelayPollInterval(interval time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// WithPublishRetries sets how many times a message is published before the drain
+// gives up, waiting backoff after the first failure and doubling it after each
+// retry. Defaults to 3 retries and 100 milliseconds.
+func WithPublishRetries(retries int, backoff time.Duration) RelayOption {
+	return func(o *relayOptions) {
+		o.retries = retries
+		o.backoff = backoff
+	}
+}
+
+// WithMaxAttempts sets after how many failed drains a message is marked as failed and
+// left out of later drains. Defaults to 10.
+func WithMaxAttempts(attempts int) RelayOption {
+	return func(o *relayOptions) {
+		o.maxAttempts = attempts
+	}
+}
+
+func newRelayOptions(opts []RelayOption) *relayOptions {
+	o := &relayOptions{
+		pollInterval: time.Second,
+		retries:      3,
+		backoff:      100 * time.Millisecond,
+		maxAttempts:  10,
+		pageSize:     100,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// OutboxRelay publishes the pending messages of an outbox table and marks them as
+// published. Delivery is at least once: a message is published again when the relay
+// stops between publishing and marking it.
+type OutboxRelay struct {
+	client    *Implementation
+	table     string
+	publisher Publisher
+	ops       *relayOptions
+}
+
+// NewOutboxRelay returns a relay of the outbox table, which must be registered on the
+// client, see OutboxTable.
+func (i *Implementation) NewOutboxRelay(outboxTable string, publisher Publisher, opts ...RelayOption) *OutboxRelay {
+	return &OutboxRelay{
+		client:    i,
+		table:     outboxTable,
+		publisher: publisher,
+		ops:       newRelayOptions(opts),
+	}
+}
+
+// Run drains the outbox every poll interval until ctx is done, then returns ctx.Err().
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	for {
+		if _, err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("[DynamoDB] failed to drain outbox %s: %s", r.table, err)
+		}
+		if err := sleepContext(ctx, r.ops.pollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// Drain publishes the pending messages in the order they were written and returns
+// how many were published. It stops at the first message that cannot be published,
+// so that later messages do not overtake it, unless the message reached the maximum
+// number of attempts and was marked as failed.
+func (r *OutboxRelay) Drain(ctx context.Context) (int, error) {
+	published := 0
+	query := KeyQuery{Index: outboxPendingIndex, PartitionKey: outboxPending, Limit: r.ops.pageSize}
+	for {
+		var messages []OutboxMessage
+		result, err := r.client.QueryKeyWithContext(ctx, r.table, query, &messages)
+		if err != nil {
+			return published, err
+		}
+		for _, message := range messages {
+			// the index is eventually consistent, skip messages already published
+			var current OutboxMessage
+			err := r.client.GetOneWithContext(ctx, r.table, message.ID, &current)
+			if errors.Is(err, ErrNotFound) || (err == nil && current.Status != outboxPending) {
+				continue
+			}
+			if err != nil {
+				return published, err
+			}
+			ok, err := r.publish(ctx, current)
+			if err != nil {
+				return published, err
+			}
+			if ok {
+				published++
+			}
+		}
+		if !result.HasMore {
+			return published, nil
+		}
+		query.Cursor = result.Cursor
+	}
+}
+
+// publish sends one message with retries, then records the outcome on its record. It
+// reports whether the message was published.
+func (r *OutboxRelay) publish(ctx context.Context, message OutboxMessage) (bool, error) {
+	backoff := r.ops.backoff
+	var err error
+	for attempt := 0; attempt <= r.ops.retries; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, backoff); err != nil {
+				return false, err
+			}
+			backoff *= 2
+		}
+		if err = r.publisher.Publish(ctx, message); err == nil {
+			break
+		}
+	}
+	pending := WithCondition(expression.Name(outboxStatusField).Equal(expression.Value(outboxPending)))
+	if err == nil {
+		update := expression.Remove(expression.Name(outboxStatusField)).
+			Set(expression.Name("published_at"), expression.Value(r.client.now().UnixNano()))
+		if err := r.client.UpdateWithContext(ctx, r.table, message.ID, update, nil, pending); err != nil && !errors.Is(err, ErrConditionFailed) {
+			return true, err
+		}
+		return true, nil
+	}
+
+	log.Printf("[DynamoDB] failed to publish message %s of outbox %s: %s", message.ID, r.table, err)
+	update := expression.Add(expression.Name("attempts"), expression.Value(1)).
+		Set(expression.Name("last_error"), expression.Value(err.Error()))
+	failed := message.Attempts+1 >= r.ops.maxAttempts
+	if failed {
+		update = update.Set(expression.Name(outboxStatusField), expression.Value(outboxFailed))
+	}
+	if updateErr := r.client.UpdateWithContext(ctx, r.table, message.ID, update, nil, pending); updateErr != nil && !errors.Is(updateErr, ErrConditionFailed) {
+		return false, updateErr
+	}
+	if failed {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to publish message %s: %w", message.ID, err)
+}
@@ -0,0 +1,177 @@
+package dynamodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/abraham-corales/go-aws/sns"
+	"github.com/abraham-corales/go-aws/sqs"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveAndPublish(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var input *dynamodb.TransactWriteItemsInput
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"orders": {TableName: "orders", PartitionKeyField: "id"}, "outbox": OutboxTable("outbox")},
+		clock:        fixedClock(&now),
+		client: &dynamoClientMock{
+			funcTransactWriteItems: func(ctx context.Context, in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+				input = in
+				return &dynamodb.TransactWriteItemsOutput{}, nil
+			},
+		},
+	}
+
+	order := map[string]interface{}{"id": "o-1", "total": 10}
+	err := client.SaveAndPublish(context.Background(), "orders", order, "outbox", Message{Type: "OrderCreated", Payload: order, GroupID: "o-1"})
+	assert.NoError(t, err)
+	assert.Len(t, input.TransactItems, 2)
+	assert.Equal(t, "orders", *input.TransactItems[0].Put.TableName)
+	put := input.TransactItems[1].Put
+	assert.Equal(t, "outbox", *put.TableName)
+	assert.Equal(t, "attribute_not_exists (#0)", *put.ConditionExpression)
+	var message OutboxMessage
+	assert.NoError(t, attributevalue.UnmarshalMapWithOptions(put.Item, &message, func(o *attributevalue.DecoderOptions) { o.TagKey = Tagkey }))
+	assert.Len(t, message.ID, 32)
+	assert.Equal(t, OutboxMessage{
+		ID:        message.ID,
+		Type:      "OrderCreated",
+		Payload:   `{"id":"o-1","total":10}`,
+		GroupID:   "o-1",
+		Status:    "pending",
+		CreatedAt: now.UnixNano(),
+	}, message)
+
+	err = client.WriteTransaction().Publish("outbox", Message{Type: "Broken", Payload: func() {}}).Commit(context.Background())
+	assert.ErrorContains(t, err, "failed to marshal message Broken")
+}
+
+// outboxMock is an in-memory outbox table serving the pending index in order.
+func outboxMock(items map[string]map[string]interface{}) *dynamoClientMock {
+	marshal := func(item map[string]interface{}) map[string]types.AttributeValue {
+		av, _ := attributevalue.MarshalMap(item)
+		return av
+	}
+	return &dynamoClientMock{
+		funcQuery: func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			var pending []map[string]interface{}
+			for _, item := range items {
+				if item["outbox_status"] == "pending" {
+					pending = append(pending, item)
+				}
+			}
+			sort.Slice(pending, func(a, b int) bool { return pending[a]["created_at"].(float64) < pending[b]["created_at"].(float64) })
+			out := &dynamodb.QueryOutput{}
+			for _, item := range pending {
+				out.Items = append(out.Items, marshal(item))
+			}
+			return out, nil
+		},
+		funcGetItem: func(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			item, ok := items[input.Key["id"].(*types.AttributeValueMemberS).Value]
+			if !ok {
+				return &dynamodb.GetItemOutput{}, nil
+			}
+			return &dynamodb.GetItemOutput{Item: marshal(item)}, nil
+		},
+		funcUpdateItem: func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			id := input.Key["id"].(*types.AttributeValueMemberS).Value
+			ok, err := evalCondition(input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, items[id])
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, &types.ConditionalCheckFailedException{}
+			}
+			items[id], err = applyUpdate(input.UpdateExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, items[id])
+			return &dynamodb.UpdateItemOutput{}, err
+		},
+	}
+}
+
+func TestOutboxRelay(t *testing.T) {
+	items := map[string]map[string]interface{}{
+		"m1": {"id": "m1", "type": "OrderCreated", "payload": "{}", "outbox_status": "pending", "created_at": float64(1), "attempts": float64(0)},
+		"m2": {"id": "m2", "type": "OrderPaid", "payload": "{}", "outbox_status": "pending", "created_at": float64(2), "attempts": float64(0)},
+		"m3": {"id": "m3", "type": "OrderShipped", "payload": "{}", "outbox_status": "pending", "created_at": float64(3), "attempts": float64(0)},
+	}
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"outbox": OutboxTable("outbox")},
+		client:       outboxMock(items),
+	}
+	var published []string
+	calls := 0
+	publisher := PublisherFunc(func(ctx context.Context, message OutboxMessage) error {
+		calls++
+		if message.ID == "m2" {
+			return errors.New("topic unavailable")
+		}
+		published = append(published, message.ID)
+		return nil
+	})
+	relay := client.NewOutboxRelay("outbox", publisher, WithPublishRetries(1, time.Millisecond), WithMaxAttempts(2))
+
+	n, err := relay.Drain(context.Background())
+	assert.ErrorContains(t, err, "failed to publish message m2: topic unavailable")
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"m1"}, published)
+	assert.Equal(t, 3, calls)
+	assert.NotContains(t, items["m1"], "outbox_status")
+	assert.Contains(t, items["m1"], "published_at")
+	assert.Equal(t, float64(1), items["m2"]["attempts"])
+	assert.Equal(t, "topic unavailable", items["m2"]["last_error"])
+	assert.Equal(t, "pending", items["m3"]["outbox_status"])
+
+	// the second failed drain parks m2 so that m3 is published
+	n, err = relay.Drain(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"m1", "m3"}, published)
+	assert.Equal(t, "failed", items["m2"]["outbox_status"])
+
+	n, err = relay.Drain(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestSNSPublisher(t *testing.T) {
+	topic := &sns.MockPublisher{}
+	topic.On("PublishWithOptions", json.RawMessage(`{"id":"o-1"}`), sns.Options{
+		MessageAttributes: map[string]any{"type": "OrderCreated", "id": "m1", "tenant": "t-1"},
+		MessageGroupID:    aws.String("o-1"),
+		DeduplicationID:   aws.String("m1"),
+	}).Return(nil).Once()
+	topic.On("PublishWithOptions", json.RawMessage(`{"id":"o-2"}`), sns.Options{
+		MessageAttributes: map[string]any{"type": "OrderCreated", "id": "m2"},
+	}).Return(errors.New("topic unavailable")).Once()
+	publisher := NewSNSPublisher(topic)
+
+	err := publisher.Publish(context.Background(), OutboxMessage{ID: "m1", Type: "OrderCreated", Payload: `{"id":"o-1"}`, GroupID: "o-1", Attributes: map[string]string{"tenant": "t-1"}})
+	assert.NoError(t, err)
+	err = publisher.Publish(context.Background(), OutboxMessage{ID: "m2", Type: "OrderCreated", Payload: `{"id":"o-2"}`})
+	assert.EqualError(t, err, "topic unavailable")
+	topic.AssertExpectations(t)
+}
+
+func TestSQSPublisher(t *testing.T) {
+	queue := &sqs.MockSpec{}
+	queue.On("SendMessageWithOptions", json.RawMessage(`{"id":"o-1"}`), sqs.Options{
+		MessageAttributes: map[string]any{"type": "OrderCreated", "id": "m1"},
+		MessageGroupID:    aws.String("o-1"),
+		DeduplicationID:   aws.String("m1"),
+	}).Return(nil, nil).Once()
+	publisher := NewSQSPublisher(queue)
+
+	err := publisher.Publish(context.Background(), OutboxMessage{ID: "m1", Type: "OrderCreated", Payload: `{"id":"o-1"}`, GroupID: "o-1"})
+	assert.NoError(t, err)
+	queue.AssertExpectations(t)
+}
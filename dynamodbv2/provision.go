@@ -0,0 +1,421 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// BillingMode is how the read and write throughput of a table is paid for.
+type BillingMode string
+
+const (
+	BillingPayPerRequest BillingMode = "PAY_PER_REQUEST"
+	BillingProvisioned   BillingMode = "PROVISIONED"
+)
+
+// ProvisionOption customizes ProvisionTables.
+type ProvisionOption func(o *provisionOptions)
+
+type provisionOptions struct {
+	pollInterval time.Duration
+}
+
+// WithPollInterval sets how often the table status is read while waiting for a table
+// to become ACTIVE. Defaults to 2 seconds.
+func WithPollInterval(interval time.Duration) ProvisionOption {
+	return func(o *provisionOptions) {
+		o.pollInterval = interval
+	}
+}
+
+func newProvisionOptions(opts []ProvisionOption) *provisionOptions {
+	o := &provisionOptions{pollInterval: 2 * time.Second}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// TableDrift is a difference between a registered table definition and the table
+// described by DynamoDB.
+type TableDrift struct {
+	Table    string
+	Property string
+	Want     string
+	Got      string
+}
+
+func (d TableDrift) String() string {
+	return fmt.Sprintf("%s: %s: want %s, got %s", d.Table, d.Property, d.Want, d.Got)
+}
+
+// ProvisionTables creates the registered tables that do not exist yet, with their
+// key schema, indexes, billing mode and TTL, and waits until every table is ACTIVE.
+// Tables that already exist are not modified; the differences between their
+// definition and DynamoDB are returned instead. Tables are processed in name order.
+func (i *Implementation) ProvisionTables(ctx context.Context, opts ...ProvisionOption) ([]TableDrift, error) {
+	ops := newProvisionOptions(opts)
+	var drift []TableDrift
+	for _, def := range i.registeredTables() {
+		created, err := i.createTable(ctx, def)
+		if err != nil {
+			return drift, err
+		}
+		desc, err := i.waitActive(ctx, def.TableName, ops.pollInterval)
+		if err != nil {
+			return drift, err
+		}
+		if created {
+			if err := i.enableTTL(ctx, def); err != nil {
+				return drift, err
+			}
+			continue
+		}
+		tableDrift, err := i.tableDrift(ctx, def, desc)
+		if err != nil {
+			return drift, err
+		}
+		drift = append(drift, tableDrift...)
+	}
+	for _, d := range drift {
+		log.Printf("[DynamoDB] table drift %s", d)
+	}
+	return drift, nil
+}
+
+// CheckTables compares every registered table definition with DynamoDB without
+// changing anything. A table that does not exist is reported as drift.
+func (i *Implementation) CheckTables(ctx context.Context) ([]TableDrift, error) {
+	var drift []TableDrift
+	for _, def := range i.registeredTables() {
+		out, err := i.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(def.TableName)})
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			drift = append(drift, TableDrift{Table: def.TableName, Property: "table", Want: "exists", Got: "missing"})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		tableDrift, err := i.tableDrift(ctx, def, out.Table)
+		if err != nil {
+			return nil, err
+		}
+		drift = append(drift, tableDrift...)
+	}
+	return drift, nil
+}
+
+func (i *Implementation) registeredTables() []DynamoTable {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	defs := make([]DynamoTable, 0, len(i.DynamoTables))
+	for _, def := range i.DynamoTables {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(a, b int) bool {
+		return defs[a].TableName < defs[b].TableName
+	})
+	return defs
+}
+
+// createTable creates the table unless it already exists.
+func (i *Implementation) createTable(ctx context.Context, def DynamoTable) (bool, error) {
+	input, err := def.createTableInput()
+	if err != nil {
+		return false, err
+	}
+	_, err = i.client.CreateTable(ctx, input)
+	var inUse *types.ResourceInUseException
+	if errors.As(err, &inUse) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create table %s: %w", def.TableName, err)
+	}
+	log.Printf("[DynamoDB] created table %s", def.TableName)
+	return true, nil
+}
+
+// waitActive polls the table until it and its global indexes are ACTIVE.
+func (i *Implementation) waitActive(ctx context.Context, table string, interval time.Duration) (*types.TableDescription, error) {
+	for {
+		out, err := i.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe table %s: %w", table, err)
+		}
+		if isActive(out.Table) {
+			return out.Table, nil
+		}
+		if err := sleepContext(ctx, interval); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func isActive(desc *types.TableDescription) bool {
+	if desc.TableStatus != types.TableStatusActive {
+		return false
+	}
+	for _, index := range desc.GlobalSecondaryIndexes {
+		if index.IndexStatus != types.IndexStatusActive {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *Implementation) enableTTL(ctx context.Context, def DynamoTable) error {
+	if def.TTLField == "" {
+		return nil
+	}
+	_, err := i.client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(def.TableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(def.TTLField),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enable TTL on table %s: %w", def.TableName, err)
+	}
+	return nil
+}
+
+func (d DynamoTable) createTableInput() (*dynamodb.CreateTableInput, error) {
+	attributes := map[string]KeyType{}
+	define := func(field string, keyType KeyType) error {
+		if keyType == "" {
+			keyType = KeyTypeString
+		}
+		if current, ok := attributes[field]; ok && current != keyType {
+			return fmt.Errorf("dynamo: attribute %s of table %s is declared as %s and %s", field, d.TableName, current, keyType)
+		}
+		attributes[field] = keyType
+		return nil
+	}
+	keySchema := func(index Index) ([]types.KeySchemaElement, error) {
+		if err := define(index.PartitionKeyField, index.PartitionKeyType); err != nil {
+			return nil, err
+		}
+		schema := []types.KeySchemaElement{{AttributeName: aws.String(index.PartitionKeyField), KeyType: types.KeyTypeHash}}
+		if index.SortKeyField != "" {
+			if err := define(index.SortKeyField, index.SortKeyType); err != nil {
+				return nil, err
+			}
+			schema = append(schema, types.KeySchemaElement{AttributeName: aws.String(index.SortKeyField), KeyType: types.KeyTypeRange})
+		}
+		return schema, nil
+	}
+
+	table, _ := d.index("")
+	schema, err := keySchema(table)
+	if err != nil {
+		return nil, err
+	}
+	throughput := d.throughput()
+	input := &dynamodb.CreateTableInput{
+		TableName:             aws.String(d.TableName),
+		KeySchema:             schema,
+		BillingMode:           types.BillingMode(d.billingMode()),
+		ProvisionedThroughput: throughput,
+	}
+
+	for _, index := range d.GlobalIndexes {
+		schema, err := keySchema(index)
+		if err != nil {
+			return nil, err
+		}
+		input.GlobalSecondaryIndexes = append(input.GlobalSecondaryIndexes, types.GlobalSecondaryIndex{
+			IndexName:             aws.String(index.Name),
+			KeySchema:             schema,
+			Projection:            index.projection(),
+			ProvisionedThroughput: throughput,
+		})
+	}
+	for _, index := range d.LocalIndexes {
+		index, _ = d.index(index.Name)
+		schema, err := keySchema(index)
+		if err != nil {
+			return nil, err
+		}
+		input.LocalSecondaryIndexes = append(input.LocalSecondaryIndexes, types.LocalSecondaryIndex{
+			IndexName:  aws.String(index.Name),
+			KeySchema:  schema,
+			Projection: index.projection(),
+		})
+	}
+
+	fields := make([]string, 0, len(attributes))
+	for field := range attributes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		input.AttributeDefinitions = append(input.AttributeDefinitions, types.AttributeDefinition{
+			AttributeName: aws.String(field),
+			AttributeType: types.ScalarAttributeType(attributes[field]),
+		})
+	}
+	return input, nil
+}
+
+func (d DynamoTable) billingMode() BillingMode {
+	if d.BillingMode == "" {
+		return BillingPayPerRequest
+	}
+	return d.BillingMode
+}
+
+func (d DynamoTable) throughput() *types.ProvisionedThroughput {
+	if d.billingMode() != BillingProvisioned {
+		return nil
+	}
+	return &types.ProvisionedThroughput{
+		ReadCapacityUnits:  aws.Int64(d.ReadCapacity),
+		WriteCapacityUnits: aws.Int64(d.WriteCapacity),
+	}
+}
+
+func (d Index) projection() *types.Projection {
+	projection := &types.Projection{ProjectionType: types.ProjectionType(d.Projection)}
+	if d.Projection == "" {
+		projection.ProjectionType = types.ProjectionTypeAll
+	}
+	if d.Projection == ProjectionInclude {
+		projection.NonKeyAttributes = d.NonKeyAttributes
+	}
+	return projection
+}
+
+// tableDrift compares the definition with the table description and its TTL.
+func (i *Implementation) tableDrift(ctx context.Context, def DynamoTable, desc *types.TableDescription) ([]TableDrift, error) {
+	var drift []TableDrift
+	compare := func(property, want, got string) {
+		if want != got {
+			drift = append(drift, TableDrift{Table: def.TableName, Property: property, Want: want, Got: got})
+		}
+	}
+	attributes := map[string]string{}
+	for _, attribute := range desc.AttributeDefinitions {
+		attributes[aws.ToString(attribute.AttributeName)] = string(attribute.AttributeType)
+	}
+
+	table, _ := def.index("")
+	wantPK, wantSK := table.keyDescription()
+	gotPK, gotSK := describeKeys(desc.KeySchema, attributes)
+	compare("partition key", wantPK, gotPK)
+	compare("sort key", wantSK, gotSK)
+
+	gotBilling := BillingProvisioned
+	if desc.BillingModeSummary != nil && desc.BillingModeSummary.BillingMode != "" {
+		gotBilling = BillingMode(desc.BillingModeSummary.BillingMode)
+	}
+	compare("billing mode", string(def.billingMode()), string(gotBilling))
+	if def.billingMode() == BillingProvisioned && gotBilling == BillingProvisioned && desc.ProvisionedThroughput != nil {
+		compare("read capacity", strconv.FormatInt(def.ReadCapacity, 10), strconv.FormatInt(aws.ToInt64(desc.ProvisionedThroughput.ReadCapacityUnits), 10))
+		compare("write capacity", strconv.FormatInt(def.WriteCapacity, 10), strconv.FormatInt(aws.ToInt64(desc.ProvisionedThroughput.WriteCapacityUnits), 10))
+	}
+
+	gotIndexes := map[string]indexDescription{}
+	for _, index := range desc.GlobalSecondaryIndexes {
+		pk, sk := describeKeys(index.KeySchema, attributes)
+		gotIndexes["global index "+aws.ToString(index.IndexName)] = indexDescription{pk, sk, index.Projection}
+	}
+	for _, index := range desc.LocalSecondaryIndexes {
+		pk, sk := describeKeys(index.KeySchema, attributes)
+		gotIndexes["local index "+aws.ToString(index.IndexName)] = indexDescription{pk, sk, index.Projection}
+	}
+	wantIndexes := map[string]Index{}
+	for _, index := range def.GlobalIndexes {
+		wantIndexes["global index "+index.Name] = index
+	}
+	for _, index := range def.LocalIndexes {
+		index, _ = def.index(index.Name)
+		wantIndexes["local index "+index.Name] = index
+	}
+	for _, name := range sortedKeys(wantIndexes) {
+		index := wantIndexes[name]
+		got, ok := gotIndexes[name]
+		if !ok {
+			compare(name, "exists", "missing")
+			continue
+		}
+		pk, sk := index.keyDescription()
+		compare(name+" partition key", pk, got.partitionKey)
+		compare(name+" sort key", sk, got.sortKey)
+		compare(name+" projection", string(index.projection().ProjectionType), string(got.projection.ProjectionType))
+	}
+	for _, name := range sortedKeys(gotIndexes) {
+		if _, ok := wantIndexes[name]; !ok {
+			compare(name, "missing", "exists")
+		}
+	}
+
+	out, err := i.client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{TableName: aws.String(def.TableName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe TTL of table %s: %w", def.TableName, err)
+	}
+	gotTTL := "disabled"
+	if ttl := out.TimeToLiveDescription; ttl != nil && (ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabled || ttl.TimeToLiveStatus == types.TimeToLiveStatusEnabling) {
+		gotTTL = aws.ToString(ttl.AttributeName)
+	}
+	wantTTL := def.TTLField
+	if wantTTL == "" {
+		wantTTL = "disabled"
+	}
+	compare("ttl", wantTTL, gotTTL)
+	return drift, nil
+}
+
+type indexDescription struct {
+	partitionKey string
+	sortKey      string
+	projection   *types.Projection
+}
+
+// keyDescription describes the keys of the index as "field (type)", or "none".
+func (d Index) keyDescription() (string, string) {
+	describe := func(field string, keyType KeyType) string {
+		if field == "" {
+			return "none"
+		}
+		if keyType == "" {
+			keyType = KeyTypeString
+		}
+		return fmt.Sprintf("%s (%s)", field, keyType)
+	}
+	return describe(d.PartitionKeyField, d.PartitionKeyType), describe(d.SortKeyField, d.SortKeyType)
+}
+
+func describeKeys(schema []types.KeySchemaElement, attributes map[string]string) (string, string) {
+	pk, sk := "none", "none"
+	for _, element := range schema {
+		name := aws.ToString(element.AttributeName)
+		description := fmt.Sprintf("%s (%s)", name, attributes[name])
+		if element.KeyType == types.KeyTypeHash {
+			pk = description
+		} else {
+			sk = description
+		}
+	}
+	return pk, sk
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
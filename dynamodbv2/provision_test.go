@@ -0,0 +1,139 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func ordersTable() DynamoTable {
+	return DynamoTable{
+		TableName:         "orders",
+		PartitionKeyField: "user",
+		SortKeyField:      "created_at",
+		SortKeyType:       KeyTypeNumber,
+		GlobalIndexes:     []Index{{Name: "by-status", PartitionKeyField: "status", SortKeyField: "created_at", SortKeyType: KeyTypeNumber, Projection: ProjectionKeysOnly}},
+		LocalIndexes:      []Index{{Name: "by-total", SortKeyField: "total", SortKeyType: KeyTypeNumber}},
+		BillingMode:       BillingProvisioned,
+		ReadCapacity:      5,
+		WriteCapacity:     2,
+		TTLField:          "expires_at",
+	}
+}
+
+func TestProvisionTablesCreatesMissingTables(t *testing.T) {
+	var created *dynamodb.CreateTableInput
+	var ttl *dynamodb.UpdateTimeToLiveInput
+	describes := 0
+	client := &Implementation{client: &dynamoClientMock{
+		funcCreateTable: func(ctx context.Context, input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+			created = input
+			return &dynamodb.CreateTableOutput{}, nil
+		},
+		funcDescribeTable: func(ctx context.Context, input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+			describes++
+			status := types.TableStatusCreating
+			if describes > 1 {
+				status = types.TableStatusActive
+			}
+			return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{TableStatus: status}}, nil
+		},
+		funcUpdateTimeToLive: func(ctx context.Context, input *dynamodb.UpdateTimeToLiveInput) (*dynamodb.UpdateTimeToLiveOutput, error) {
+			ttl = input
+			return &dynamodb.UpdateTimeToLiveOutput{}, nil
+		},
+	}}
+	WithTable(ordersTable())(client)
+
+	drift, err := client.ProvisionTables(context.Background(), WithPollInterval(time.Millisecond))
+	assert.NoError(t, err)
+	assert.Empty(t, drift)
+	assert.Equal(t, 2, describes)
+
+	assert.Equal(t, []types.AttributeDefinition{
+		{AttributeName: aws.String("created_at"), AttributeType: types.ScalarAttributeTypeN},
+		{AttributeName: aws.String("status"), AttributeType: types.ScalarAttributeTypeS},
+		{AttributeName: aws.String("total"), AttributeType: types.ScalarAttributeTypeN},
+		{AttributeName: aws.String("user"), AttributeType: types.ScalarAttributeTypeS},
+	}, created.AttributeDefinitions)
+	assert.Equal(t, []types.KeySchemaElement{
+		{AttributeName: aws.String("user"), KeyType: types.KeyTypeHash},
+		{AttributeName: aws.String("created_at"), KeyType: types.KeyTypeRange},
+	}, created.KeySchema)
+	assert.Equal(t, types.BillingModeProvisioned, created.BillingMode)
+	assert.Equal(t, int64(5), *created.ProvisionedThroughput.ReadCapacityUnits)
+	assert.Equal(t, types.ProjectionTypeKeysOnly, created.GlobalSecondaryIndexes[0].Projection.ProjectionType)
+	assert.Equal(t, int64(2), *created.GlobalSecondaryIndexes[0].ProvisionedThroughput.WriteCapacityUnits)
+	assert.Equal(t, "user", *created.LocalSecondaryIndexes[0].KeySchema[0].AttributeName)
+	assert.Equal(t, types.ProjectionTypeAll, created.LocalSecondaryIndexes[0].Projection.ProjectionType)
+	assert.Equal(t, "expires_at", *ttl.TimeToLiveSpecification.AttributeName)
+}
+
+func TestProvisionTablesReportsDrift(t *testing.T) {
+	client := &Implementation{client: &dynamoClientMock{
+		funcCreateTable: func(ctx context.Context, input *dynamodb.CreateTableInput) (*dynamodb.CreateTableOutput, error) {
+			return nil, &types.ResourceInUseException{Message: aws.String("Table already exists")}
+		},
+		funcDescribeTable: func(ctx context.Context, input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+			return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{
+				TableStatus: types.TableStatusActive,
+				AttributeDefinitions: []types.AttributeDefinition{
+					{AttributeName: aws.String("user"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("created_at"), AttributeType: types.ScalarAttributeTypeS},
+					{AttributeName: aws.String("status"), AttributeType: types.ScalarAttributeTypeS},
+				},
+				KeySchema: []types.KeySchemaElement{
+					{AttributeName: aws.String("user"), KeyType: types.KeyTypeHash},
+					{AttributeName: aws.String("created_at"), KeyType: types.KeyTypeRange},
+				},
+				BillingModeSummary: &types.BillingModeSummary{BillingMode: types.BillingModePayPerRequest},
+				GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{{
+					IndexName:   aws.String("by-status"),
+					IndexStatus: types.IndexStatusActive,
+					KeySchema:   []types.KeySchemaElement{{AttributeName: aws.String("status"), KeyType: types.KeyTypeHash}},
+					Projection:  &types.Projection{ProjectionType: types.ProjectionTypeKeysOnly},
+				}},
+			}}, nil
+		},
+		funcDescribeTimeToLive: func(ctx context.Context, input *dynamodb.DescribeTimeToLiveInput) (*dynamodb.DescribeTimeToLiveOutput, error) {
+			return &dynamodb.DescribeTimeToLiveOutput{TimeToLiveDescription: &types.TimeToLiveDescription{TimeToLiveStatus: types.TimeToLiveStatusDisabled}}, nil
+		},
+	}}
+	WithTable(ordersTable())(client)
+
+	drift, err := client.ProvisionTables(context.Background())
+	assert.NoError(t, err)
+	var lines []string
+	for _, d := range drift {
+		lines = append(lines, d.String())
+	}
+	assert.Equal(t, []string{
+		"orders: sort key: want created_at (N), got created_at (S)",
+		"orders: billing mode: want PROVISIONED, got PAY_PER_REQUEST",
+		"orders: global index by-status sort key: want created_at (N), got none",
+		"orders: local index by-total: want exists, got missing",
+		"orders: ttl: want expires_at, got disabled",
+	}, lines)
+}
+
+func TestCheckTablesMissingTable(t *testing.T) {
+	client := &Implementation{client: &dynamoClientMock{
+		funcDescribeTable: func(ctx context.Context, input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+			return nil, &types.ResourceNotFoundException{Message: aws.String("Requested resource not found")}
+		},
+	}}
+	WithTable(ordersTable())(client)
+
+	drift, err := client.CheckTables(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []TableDrift{{Table: "orders", Property: "table", Want: "exists", Got: "missing"}}, drift)
+
+	WithTable(DynamoTable{TableName: "broken", PartitionKeyField: "id", SortKeyField: "id", SortKeyType: KeyTypeNumber})(client)
+	_, err = client.ProvisionTables(context.Background())
+	assert.EqualError(t, err, "dynamo: attribute id of table broken is declared as S and N")
+}
@@ -0,0 +1,168 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// KeyQuery reads the items of one partition of a table or index, optionally restricted
+// to a range of sort keys. Key values must match the key types of their definition.
+//
+//	result, err := client.QueryKey("orders", dynamodb.KeyQuery{
+//		PartitionKey: "user-1",
+//		SortKey:      dynamodb.SortGreaterThanEqual(weekAgo),
+//		Descending:   true,
+//		Limit:        20,
+//	}, &orders)
+type KeyQuery struct {
+	// Index is the name of a secondary index of the table. The key values are then
+	// those of the index.
+	Index        string
+	PartitionKey interface{}
+	// SortKey restricts the sort keys read. A nil SortKey reads the whole partition.
+	SortKey *SortCondition
+	// Descending returns the items in descending sort key order.
+	Descending bool
+	// Limit is the maximum number of items returned, the table MaxPageSize when 0.
+	// Without any limit a single DynamoDB response is returned.
+	Limit int32
+	// Cursor resumes the query from the Cursor of a previous QueryResult.
+	Cursor string
+}
+
+// SortCondition is a condition on the sort key of a KeyQuery.
+type SortCondition struct {
+	operator string
+	values   []interface{}
+}
+
+// SortEqual matches the sort key equal to value.
+func SortEqual(value interface{}) *SortCondition {
+	return &SortCondition{operator: "=", values: []interface{}{value}}
+}
+
+// SortLessThan matches sort keys lower than value.
+func SortLessThan(value interface{}) *SortCondition {
+	return &SortCondition{operator: "<", values: []interface{}{value}}
+}
+
+// SortLessThanEqual matches sort keys lower than or equal to value.
+func SortLessThanEqual(value interface{}) *SortCondition {
+	return &SortCondition{operator: "<=", values: []interface{}{value}}
+}
+
+// SortGreaterThan matches sort keys greater than value.
+func SortGreaterThan(value interface{}) *SortCondition {
+	return &SortCondition{operator: ">", values: []interface{}{value}}
+}
+
+// SortGreaterThanEqual matches sort keys greater than or equal to value.
+func SortGreaterThanEqual(value interface{}) *SortCondition {
+	return &SortCondition{operator: ">=", values: []interface{}{value}}
+}
+
+// SortBetween matches sort keys from lower to upper, both included.
+func SortBetween(lower, upper interface{}) *SortCondition {
+	return &SortCondition{operator: "between", values: []interface{}{lower, upper}}
+}
+
+// SortBeginsWith matches sort keys starting with prefix. It is only supported on
+// string sort keys: the expression package builds string prefixes only, so QueryKey
+// fails with ErrInvalidKey on number and binary sort keys. Use SortBetween to read a
+// range of binary sort keys instead.
+func SortBeginsWith(prefix interface{}) *SortCondition {
+	return &SortCondition{operator: "begins_with", values: []interface{}{prefix}}
+}
+
+// keyExpression builds the key condition of a KeyQuery on the table or the index it
+// names, validating the key values against their definition.
+func (d DynamoTable) keyExpression(query KeyQuery) (expression.Expression, error) {
+	index, err := d.index(query.Index)
+	if err != nil {
+		return expression.Expression{}, err
+	}
+	if index.PartitionKeyField == "" {
+		// the legacy GlobalIndex only holds the index name, not its key schema
+		return expression.Expression{}, fmt.Errorf("%w: %s on table %s has no key schema, declare it in GlobalIndexes", ErrUnknownIndex, query.Index, d.TableName)
+	}
+	pk, err := keyValue(index.PartitionKeyField, index.PartitionKeyType, query.PartitionKey)
+	if err != nil {
+		return expression.Expression{}, err
+	}
+	keyEx := expression.Key(index.PartitionKeyField).Equal(expression.Value(pk))
+
+	if query.SortKey != nil {
+		if index.SortKeyField == "" {
+			return expression.Expression{}, fmt.Errorf("%w: no sort key to query on table %s", ErrInvalidKey, d.TableName)
+		}
+		sortEx, err := index.sortKeyCondition(query.SortKey)
+		if err != nil {
+			return expression.Expression{}, err
+		}
+		keyEx = keyEx.And(sortEx)
+	}
+	return expression.NewBuilder().WithKeyCondition(keyEx).Build()
+}
+
+func (d Index) sortKeyCondition(condition *SortCondition) (expression.KeyConditionBuilder, error) {
+	var attributes []types.AttributeValue
+	var values []expression.ValueBuilder
+	for _, v := range condition.values {
+		sk, err := keyValue(d.SortKeyField, d.SortKeyType, v)
+		if err != nil {
+			return expression.KeyConditionBuilder{}, err
+		}
+		attributes = append(attributes, sk)
+		values = append(values, expression.Value(sk))
+	}
+
+	key := expression.Key(d.SortKeyField)
+	switch condition.operator {
+	case "=":
+		return key.Equal(values[0]), nil
+	case "<":
+		return key.LessThan(values[0]), nil
+	case "<=":
+		return key.LessThanEqual(values[0]), nil
+	case ">":
+		return key.GreaterThan(values[0]), nil
+	case ">=":
+		return key.GreaterThanEqual(values[0]), nil
+	case "between":
+		return key.Between(values[0], values[1]), nil
+	case "begins_with":
+		prefix, ok := attributes[0].(*types.AttributeValueMemberS)
+		if !ok {
+			return expression.KeyConditionBuilder{}, fmt.Errorf("%w: begins_with is only supported on string sort keys, %s is of type %s", ErrInvalidKey, d.SortKeyField, d.SortKeyType)
+		}
+		return key.BeginsWith(prefix.Value), nil
+	}
+	return expression.KeyConditionBuilder{}, fmt.Errorf("%w: unknown sort key condition %q", ErrInvalidKey, condition.operator)
+}
+
+// QueryKey returns the items of a partition matching the KeyQuery, in sort key order.
+// The result holds the cursor of the next page when more items are left.
+func (i *Implementation) QueryKey(table string, query KeyQuery, bindTo interface{}) (*QueryResult, error) {
+	return i.QueryKeyWithContext(context.TODO(), table, query, bindTo)
+}
+
+func (i *Implementation) QueryKeyWithContext(ctx context.Context, table string, query KeyQuery, bindTo interface{}) (*QueryResult, error) {
+	log.Printf("[DynamoDB] executing key query with [pk:%v]", query.PartitionKey)
+
+	def, err := i.table(table)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := def.keyExpression(query)
+	if err != nil {
+		return nil, err
+	}
+	queryInput := buildQueryInput(def.TableName, query.Index, expr, nil)
+	queryInput.ScanIndexForward = aws.Bool(!query.Descending)
+	return i.readPage(ctx, def, queryInput, query.Limit, query.Cursor, bindTo)
+}
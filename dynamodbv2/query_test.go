@@ -0,0 +1,397 @@
+package dynamodb
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryKey(t *testing.T) {
+	var input *dynamodb.QueryInput
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{
+			"events":   {TableName: "events", PartitionKeyField: "device", SortKeyField: "at", SortKeyType: KeyTypeNumber},
+			"accounts": {TableName: "accounts", PartitionKeyField: "id"},
+		},
+		client: &dynamoClientMock{
+			funcQuery: func(ctx context.Context, in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+				input = in
+				return &dynamodb.QueryOutput{
+					Items:            []map[string]types.AttributeValue{{"device": &types.AttributeValueMemberS{Value: "d-1"}}},
+					Count:            1,
+					ScannedCount:     1,
+					LastEvaluatedKey: map[string]types.AttributeValue{"device": &types.AttributeValueMemberS{Value: "d-1"}},
+				}, nil
+			},
+		},
+	}
+
+	var events []map[string]interface{}
+	result, err := client.QueryKey("events", KeyQuery{
+		PartitionKey: "d-1",
+		SortKey:      SortBetween(100, 200),
+		Descending:   true,
+		Limit:        1,
+	}, &events)
+	assert.NoError(t, err)
+	assert.Equal(t, "(#0 = :0) AND (#1 BETWEEN :1 AND :2)", *input.KeyConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "100"}, input.ExpressionAttributeValues[":1"])
+	assert.False(t, *input.ScanIndexForward)
+	assert.Equal(t, int32(1), *input.Limit)
+	assert.Len(t, events, 1)
+	assert.True(t, result.HasMore)
+	assert.NotEmpty(t, result.Cursor)
+
+	_, err = client.QueryKey("events", KeyQuery{PartitionKey: "d-1", SortKey: SortGreaterThanEqual(100), Cursor: result.Cursor}, &events)
+	assert.NoError(t, err)
+	assert.Equal(t, "(#0 = :0) AND (#1 >= :1)", *input.KeyConditionExpression)
+	assert.True(t, *input.ScanIndexForward)
+	assert.Equal(t, result.Cursor, mustCursor(t, client, input.ExclusiveStartKey))
+
+	_, err = client.QueryKey("events", KeyQuery{PartitionKey: "d-1", SortKey: SortBeginsWith("1")}, &events)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = client.QueryKey("events", KeyQuery{PartitionKey: "d-1", SortKey: SortLessThan("1")}, &events)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	_, err = client.QueryKey("accounts", KeyQuery{PartitionKey: "1", SortKey: SortEqual("a")}, &events)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestQueryKeyBeginsWithBinarySortKey(t *testing.T) {
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{
+			"files": {TableName: "files", PartitionKeyField: "bucket", SortKeyField: "hash", SortKeyType: KeyTypeBinary},
+		},
+		client: &dynamoClientMock{
+			funcQuery: func(ctx context.Context, in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+				t.Fatal("no request expected")
+				return nil, nil
+			},
+		},
+	}
+
+	var files []map[string]interface{}
+	_, err := client.QueryKey("files", KeyQuery{PartitionKey: "b-1", SortKey: SortBeginsWith([]byte{0x01})}, &files)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+	assert.ErrorContains(t, err, "hash is of type B")
+	_, err = client.QueryKey("files", KeyQuery{PartitionKey: "b-1", SortKey: SortBeginsWith("a")}, &files)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func mustCursor(t *testing.T, client *Implementation, key map[string]types.AttributeValue) string {
+	cursor, err := client.encodeCursor(key)
+	assert.NoError(t, err)
+	return cursor
+}
+
+func TestDynamoLocalDevelopmentQueryKey(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+			SortKeyField:      "name",
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	var persons []person
+	result, err := client.QueryKey("person", KeyQuery{PartitionKey: "3"}, &persons)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), result.Count)
+	assert.Equal(t, "Janet", persons[0].Name)
+	assert.Equal(t, "Suzanne", persons[1].Name)
+
+	persons = nil
+	result, err = client.QueryKey("person", KeyQuery{PartitionKey: "3", SortKey: SortBeginsWith("J")}, &persons)
+	assert.NoError(t, err)
+	assert.Len(t, persons, 1)
+	assert.Equal(t, "Janet", persons[0].Name)
+
+	persons = nil
+	result, err = client.QueryKey("person", KeyQuery{PartitionKey: "3", Descending: true, Limit: 1}, &persons)
+	assert.NoError(t, err)
+	assert.Equal(t, "Suzanne", persons[0].Name)
+	assert.True(t, result.HasMore)
+
+	persons = nil
+	result, err = client.QueryKey("person", KeyQuery{PartitionKey: "3", Descending: true, Limit: 1, Cursor: result.Cursor}, &persons)
+	assert.NoError(t, err)
+	assert.Equal(t, "Janet", persons[0].Name)
+	assert.False(t, result.HasMore)
+
+	persons = nil
+	_, err = client.QueryKey("person", KeyQuery{PartitionKey: "3", SortKey: SortBetween("K", "Z")}, &persons)
+	assert.NoError(t, err)
+	assert.Len(t, persons, 1)
+	assert.Equal(t, "Suzanne", persons[0].Name)
+}
+
+func TestQueryKeyOnIndexes(t *testing.T) {
+	var input *dynamodb.QueryInput
+	client := &Implementation{client: &dynamoClientMock{
+		funcQuery: func(ctx context.Context, in *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			input = in
+			return &dynamodb.QueryOutput{ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1)}}, nil
+		},
+	}}
+	WithTable(DynamoTable{
+		TableName:         "orders",
+		PartitionKeyField: "user",
+		SortKeyField:      "order_id",
+		GlobalIndexes: []Index{{
+			Name:              "by-status",
+			PartitionKeyField: "status",
+			SortKeyField:      "total",
+			SortKeyType:       KeyTypeNumber,
+			Projection:        ProjectionKeysOnly,
+		}},
+		LocalIndexes: []Index{{Name: "by-date", SortKeyField: "created_at"}},
+		GlobalIndex:  "legacy-index",
+	})(client)
+	assert.Len(t, client.DynamoTables["orders"].GlobalIndexes, 1)
+
+	var orders []order
+	_, err := client.QueryKey("orders", KeyQuery{Index: "by-status", PartitionKey: "paid", SortKey: SortGreaterThan(100)}, &orders)
+	assert.NoError(t, err)
+	assert.Equal(t, "by-status", *input.IndexName)
+	assert.Equal(t, map[string]string{"#0": "status", "#1": "total"}, input.ExpressionAttributeNames)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "100"}, input.ExpressionAttributeValues[":1"])
+
+	_, err = client.QueryKey("orders", KeyQuery{Index: "by-date", PartitionKey: "user-1", SortKey: SortBeginsWith("2024-")}, &orders)
+	assert.NoError(t, err)
+	assert.Equal(t, "by-date", *input.IndexName)
+	assert.Equal(t, map[string]string{"#0": "user", "#1": "created_at"}, input.ExpressionAttributeNames)
+
+	_, err = client.QueryKey("orders", KeyQuery{Index: "by-status", PartitionKey: "paid", SortKey: SortGreaterThan("100")}, &orders)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+
+	input = nil
+	_, err = client.QueryKey("orders", KeyQuery{Index: "by-total", PartitionKey: "paid"}, &orders)
+	assert.ErrorIs(t, err, ErrUnknownIndex)
+	assert.EqualError(t, err, "dynamo: unknown index: by-total on table orders")
+	query, err := client.DynamoTables["orders"].keyExpression(KeyQuery{PartitionKey: "user-1"})
+	assert.NoError(t, err)
+	err = client.QueryGSI("orders", "by-total", query, 0, 0, &orders)
+	assert.ErrorIs(t, err, ErrUnknownIndex)
+	_, err = client.QueryGSIPage("orders", "by-total", query, 0, "", &orders)
+	assert.ErrorIs(t, err, ErrUnknownIndex)
+	assert.Nil(t, input)
+
+	err = client.QueryGSI("orders", "legacy-index", query, 0, 0, &orders)
+	assert.NoError(t, err)
+	assert.Equal(t, "legacy-index", *input.IndexName)
+
+	input = nil
+	_, err = client.QueryKey("orders", KeyQuery{Index: "legacy-index", PartitionKey: "paid"}, &orders)
+	assert.ErrorIs(t, err, ErrUnknownIndex)
+	assert.EqualError(t, err, "dynamo: unknown index: legacy-index on table orders has no key schema, declare it in GlobalIndexes")
+	assert.Nil(t, input)
+}
+
+func TestDynamoLocalDevelopmentQueryKeyOnIndex(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+			SortKeyField:      "name",
+			GlobalIndexes:     []Index{{Name: "by-phone", PartitionKeyField: "phone", SortKeyField: "age"}},
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	var persons []person
+	_, err := client.QueryKey("person", KeyQuery{Index: "by-phone", PartitionKey: "1234567890", SortKey: SortGreaterThanEqual("40"), Descending: true}, &persons)
+	assert.NoError(t, err)
+	assert.Len(t, persons, 3)
+	assert.Equal(t, "Janet", persons[0].Name)
+
+	_, err = client.QueryKey("person", KeyQuery{Index: "by-city", PartitionKey: "Paris"}, &persons)
+	assert.ErrorIs(t, err, ErrUnknownIndex)
+}
+
+func TestDynamoLocalDevelopmentQueryExpression(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+			SortKeyField:      "name",
+			GlobalIndexes:     []Index{{Name: "by-phone", PartitionKeyField: "phone", SortKeyField: "age"}},
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	query, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key("id").Equal(expression.Value("3"))).
+		Build()
+	assert.NoError(t, err)
+	var persons []person
+	result, err := client.QueryExpressionResult("person", query, 0, 0, &persons)
+	assert.NoError(t, err)
+	assert.Equal(t, []person{
+		{Id: "3", Name: "Janet", Age: "60", City: "Tokyo", Country: "Japan", Phone: "1234567890"},
+		{Id: "3", Name: "Suzanne", Age: "50", City: "Paris", Country: "France", Phone: "1234567890"},
+	}, persons)
+	assert.Equal(t, &QueryResult{Count: 2, ScannedCount: 2, Pages: 1}, result)
+
+	persons = nil
+	result, err = client.QueryExpressionResult("person", query, 1, 1, &persons)
+	assert.NoError(t, err)
+	assert.Equal(t, "Janet", persons[0].Name)
+	assert.Equal(t, &QueryResult{Count: 1, ScannedCount: 1, Pages: 1, HasMore: true, Cursor: "1"}, result)
+
+	persons = nil
+	err = client.QueryExpression("person", query, 1, 2, &persons)
+	assert.NoError(t, err)
+	assert.Equal(t, "Suzanne", persons[0].Name)
+
+	query, err = expression.NewBuilder().
+		WithKeyCondition(expression.Key("phone").Equal(expression.Value("1234567890"))).
+		WithFilter(expression.Name("country").NotEqual(expression.Value("UK"))).
+		Build()
+	assert.NoError(t, err)
+	persons = nil
+	err = client.QueryGSIWithContext(context.Background(), "person", "by-phone", query, 0, 0, &persons)
+	assert.NoError(t, err)
+	assert.Len(t, persons, 3)
+	assert.Equal(t, []string{"John", "Suzanne", "Janet"}, []string{persons[0].Name, persons[1].Name, persons[2].Name})
+
+	persons = nil
+	result, err = client.QueryGSIPage("person", "by-phone", query, 2, "", &persons)
+	assert.NoError(t, err)
+	assert.Len(t, persons, 2)
+	assert.True(t, result.HasMore)
+	persons = nil
+	result, err = client.QueryGSIPage("person", "by-phone", query, 2, result.Cursor, &persons)
+	assert.NoError(t, err)
+	assert.Equal(t, "Janet", persons[0].Name)
+	assert.False(t, result.HasMore)
+
+	err = client.QueryGSI("person", "by-city", query, 0, 0, &persons)
+	assert.ErrorIs(t, err, ErrUnknownIndex)
+}
+
+func TestQueryExpressionReturnsResult(t *testing.T) {
+	calls := 0
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"orders": {TableName: "orders", PartitionKeyField: "user", SortKeyField: "order_id"}},
+		client: &dynamoClientMock{
+			funcQuery: func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+				calls++
+				output := &dynamodb.QueryOutput{
+					Items:        []map[string]types.AttributeValue{orderItem(strconv.Itoa(calls), "paid")},
+					Count:        1,
+					ScannedCount: 2,
+				}
+				// the second response reports no consumed capacity
+				if calls != 2 {
+					output.ConsumedCapacity = &types.ConsumedCapacity{CapacityUnits: aws.Float64(0.5)}
+				}
+				if calls < 3 {
+					output.LastEvaluatedKey = map[string]types.AttributeValue{
+						"user":     &types.AttributeValueMemberS{Value: "1"},
+						"order_id": &types.AttributeValueMemberS{Value: strconv.Itoa(calls)},
+					}
+				}
+				return output, nil
+			},
+		},
+	}
+	query, err := expression.NewBuilder().WithKeyCondition(expression.Key("user").Equal(expression.Value("1"))).Build()
+	assert.NoError(t, err)
+
+	var orders []order
+	result, err := client.QueryExpressionResult("orders", query, 0, 0, &orders)
+	assert.NoError(t, err)
+	assert.Len(t, orders, 3)
+	assert.Equal(t, &QueryResult{Count: 3, ScannedCount: 6, ConsumedCapacity: 1, Pages: 3}, result)
+
+	calls = 0
+	orders = nil
+	result, err = client.QueryGSIResult("orders", "", query, 1, 2, &orders)
+	assert.NoError(t, err)
+	assert.Equal(t, []order{{ID: "2", Status: "paid", Total: 10}}, orders)
+	assert.Equal(t, int32(1), result.Count)
+	assert.Equal(t, 2, result.Pages)
+	assert.Equal(t, 0.5, result.ConsumedCapacity)
+	assert.True(t, result.HasMore)
+	assert.NotEmpty(t, result.Cursor)
+}
+
+func TestQueryItems(t *testing.T) {
+	var queries []*dynamodb.QueryInput
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"orders": {TableName: "orders", PartitionKeyField: "user", SortKeyField: "order_id"}},
+		client: &dynamoClientMock{
+			funcQuery: func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+				queries = append(queries, input)
+				page := strconv.Itoa(len(queries))
+				return &dynamodb.QueryOutput{
+					Items:            []map[string]types.AttributeValue{orderItem(page+"-1", "paid"), orderItem(page+"-2", "new")},
+					LastEvaluatedKey: map[string]types.AttributeValue{"order_id": &types.AttributeValueMemberS{Value: page + "-2"}},
+				}, nil
+			},
+		},
+	}
+	query, err := expression.NewBuilder().WithKeyCondition(expression.Key("user").Equal(expression.Value("1"))).Build()
+	assert.NoError(t, err)
+
+	var statuses []string
+	for item, err := range client.QueryItems(context.Background(), "orders", query) {
+		assert.NoError(t, err)
+		statuses = append(statuses, item["status"].(*types.AttributeValueMemberS).Value)
+		if len(statuses) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"paid", "new"}, statuses)
+	assert.Len(t, queries, 1)
+
+	var ids []string
+	for o, err := range Decode[order](client.QueryGSIItems(context.Background(), "orders", "", query)) {
+		assert.NoError(t, err)
+		ids = append(ids, o.ID)
+		if len(ids) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"2-1", "2-2", "3-1"}, ids)
+
+	for _, err := range client.QueryGSIItems(context.Background(), "orders", "by-total", query) {
+		assert.ErrorIs(t, err, ErrUnknownIndex)
+	}
+}
+
+func TestDynamoLocalDevelopmentQueryItems(t *testing.T) {
+	var client Client = NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "person",
+			PartitionKeyField: "id",
+			SortKeyField:      "name",
+		}).
+		WithPreloadedItems("person", "/test.json")
+
+	query, err := expression.NewBuilder().WithKeyCondition(expression.Key("id").Equal(expression.Value("3"))).Build()
+	assert.NoError(t, err)
+	var names []string
+	for item, err := range client.QueryItems(context.Background(), "person", query) {
+		assert.NoError(t, err)
+		var p struct {
+			Name string `dynamo:"name"`
+		}
+		assert.NoError(t, item.Unmarshal(&p))
+		names = append(names, p.Name)
+	}
+	assert.Equal(t, []string{"Janet", "Suzanne"}, names)
+
+	filter, err := expression.NewBuilder().WithFilter(expression.Name("city").Equal(expression.Value("Paris"))).Build()
+	assert.NoError(t, err)
+	count := 0
+	for item, err := range client.ScanItems(context.Background(), "person", filter) {
+		assert.NoError(t, err)
+		assert.Equal(t, &types.AttributeValueMemberS{Value: "Paris"}, item["city"])
+		count++
+	}
+	assert.Positive(t, count)
+}
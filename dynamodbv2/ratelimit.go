@@ -0,0 +1,334 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+)
+
+// RateLimit caps the capacity units per second a client consumes on a table, so that
+// bulk jobs leave capacity to the other clients of the table. Zero values do not
+// limit.
+type RateLimit struct {
+	ReadUnits  float64 `json:"read_units"`
+	WriteUnits float64 `json:"write_units"`
+}
+
+// Throttling feedback of a limiter: a throttled request halves the rate, down to
+// minRateShare of the limit, and the rate recovers by recoveryShare of the limit
+// every second without throttling.
+const (
+	minRateShare  = 0.1
+	recoveryShare = 0.05
+)
+
+// bucket is a token bucket of capacity units holding up to one second of the limit,
+// and at least one unit. Each request takes one unit up front and the difference with
+// the units it consumed is settled afterwards, which may leave the bucket in debt
+// until it refills.
+type bucket struct {
+	mu     sync.Mutex
+	limit  float64
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(limit float64, now time.Time) *bucket {
+	if limit <= 0 {
+		return nil
+	}
+	return &bucket{limit: limit, rate: limit, tokens: math.Max(limit, 1), last: now}
+}
+
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens = math.Min(math.Max(b.limit, 1), b.tokens+elapsed*b.rate)
+	b.rate = math.Min(b.limit, b.rate+elapsed*b.limit*recoveryShare)
+	b.last = now
+}
+
+// take waits until the bucket holds a unit and takes it.
+func (b *bucket) take(ctx context.Context, now func() time.Time, sleep func(context.Context, time.Duration) error) error {
+	for {
+		b.mu.Lock()
+		b.refill(now())
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration(math.Ceil((1 - b.tokens) / b.rate * float64(time.Second)))
+		b.mu.Unlock()
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// settle replaces the unit taken for a request by the units it consumed.
+func (b *bucket) settle(consumed float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += 1 - consumed
+}
+
+// throttled slows the bucket down after DynamoDB throttled a request.
+func (b *bucket) throttled() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate = math.Max(b.rate/2, b.limit*minRateShare)
+}
+
+// rateLimiter holds the read and write buckets of a table, nil when not limited.
+type rateLimiter struct {
+	read  *bucket
+	write *bucket
+}
+
+func newRateLimiter(limit RateLimit, now time.Time) *rateLimiter {
+	if limit.ReadUnits <= 0 && limit.WriteUnits <= 0 {
+		return nil
+	}
+	return &rateLimiter{read: newBucket(limit.ReadUnits, now), write: newBucket(limit.WriteUnits, now)}
+}
+
+// limiter returns the bucket of a table for reads or writes, or nil.
+func (i *Implementation) limiter(table string, write bool) *bucket {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	limiter := i.limiters[table]
+	switch {
+	case limiter == nil:
+		return nil
+	case write:
+		return limiter.write
+	default:
+		return limiter.read
+	}
+}
+
+// limitedAPI applies the rate limits of the registered tables to the requests sent
+// to DynamoDB. Requests on tables without limits are sent as they are.
+type limitedAPI struct {
+	dynamoAPI
+	client *Implementation
+	sleep  func(ctx context.Context, d time.Duration) error
+}
+
+// wait takes a unit from the bucket of every limited table of a request and returns
+// the buckets to settle.
+func (l *limitedAPI) wait(ctx context.Context, write bool, tables ...string) (map[string]*bucket, error) {
+	var buckets map[string]*bucket
+	for _, table := range tables {
+		b := l.client.limiter(table, write)
+		if b == nil || buckets[table] != nil {
+			continue
+		}
+		if err := b.take(ctx, l.client.now, l.sleep); err != nil {
+			return nil, err
+		}
+		if buckets == nil {
+			buckets = make(map[string]*bucket)
+		}
+		buckets[table] = b
+	}
+	return buckets, nil
+}
+
+// limited sends a request once the buckets of its tables allow it. It asks for the
+// consumed capacity on a copy of the input, so the caller's input is left untouched,
+// and settles the buckets with the capacity of the response.
+func limited[In, Out any](ctx context.Context, l *limitedAPI, write bool, tables []string, params *In, optFns []func(*dynamodb.Options),
+	returnCapacity func(*In) *types.ReturnConsumedCapacity,
+	send func(context.Context, *In, ...func(*dynamodb.Options)) (*Out, error),
+	consumed func(*Out) []types.ConsumedCapacity,
+) (*Out, error) {
+	buckets, err := l.wait(ctx, write, tables...)
+	if err != nil {
+		return nil, err
+	}
+	if buckets == nil {
+		return send(ctx, params, optFns...)
+	}
+	input := *params
+	if c := returnCapacity(&input); *c == "" || *c == types.ReturnConsumedCapacityNone {
+		*c = types.ReturnConsumedCapacityTotal
+	}
+	out, err := send(ctx, &input, append(slices.Clip(optFns), observeThrottling(buckets))...)
+	if out != nil {
+		settle(buckets, consumed(out))
+	}
+	return out, err
+}
+
+// observeThrottling slows the buckets down on every throttled attempt of a request,
+// including the ones the SDK retries, by adding a middleware inside the retry loop.
+func observeThrottling(buckets map[string]*bucket) func(*dynamodb.Options) {
+	observe := middleware.FinalizeMiddlewareFunc("RateLimitThrottling", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+		out, metadata, err := next.HandleFinalize(ctx, in)
+		if isThrottled(err) {
+			for _, b := range buckets {
+				b.throttled()
+			}
+		}
+		return out, metadata, err
+	})
+	return func(o *dynamodb.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			if _, ok := stack.Finalize.Get("Retry"); !ok {
+				return stack.Finalize.Add(observe, middleware.After)
+			}
+			return stack.Finalize.Insert(observe, "Retry", middleware.After)
+		})
+	}
+}
+
+// settle replaces the unit taken for a request by the capacity it consumed.
+func settle(buckets map[string]*bucket, capacity []types.ConsumedCapacity) {
+	for _, c := range capacity {
+		if b := buckets[aws.ToString(c.TableName)]; b != nil {
+			b.settle(aws.ToFloat64(c.CapacityUnits))
+		}
+	}
+}
+
+// capacityOf returns the consumed capacity of a single table request.
+func capacityOf(table *string, capacity *types.ConsumedCapacity) []types.ConsumedCapacity {
+	if capacity == nil {
+		return nil
+	}
+	c := *capacity
+	if c.TableName == nil {
+		c.TableName = table
+	}
+	return []types.ConsumedCapacity{c}
+}
+
+func isThrottled(err error) bool {
+	var throughput *types.ProvisionedThroughputExceededException
+	var requestLimit *types.RequestLimitExceeded
+	var apiErr smithy.APIError
+	return errors.As(err, &throughput) || errors.As(err, &requestLimit) ||
+		(errors.As(err, &apiErr) && apiErr.ErrorCode() == "ThrottlingException")
+}
+
+func (l *limitedAPI) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return limited(ctx, l, false, []string{aws.ToString(params.TableName)}, params, optFns,
+		func(in *dynamodb.GetItemInput) *types.ReturnConsumedCapacity { return &in.ReturnConsumedCapacity },
+		l.dynamoAPI.GetItem,
+		func(out *dynamodb.GetItemOutput) []types.ConsumedCapacity {
+			return capacityOf(params.TableName, out.ConsumedCapacity)
+		})
+}
+
+func (l *limitedAPI) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	return limited(ctx, l, false, []string{aws.ToString(params.TableName)}, params, optFns,
+		func(in *dynamodb.QueryInput) *types.ReturnConsumedCapacity { return &in.ReturnConsumedCapacity },
+		l.dynamoAPI.Query,
+		func(out *dynamodb.QueryOutput) []types.ConsumedCapacity {
+			return capacityOf(params.TableName, out.ConsumedCapacity)
+		})
+}
+
+func (l *limitedAPI) Scan(ctx context.Context, params *dynamodb.ScanInput, optFns ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	return limited(ctx, l, false, []string{aws.ToString(params.TableName)}, params, optFns,
+		func(in *dynamodb.ScanInput) *types.ReturnConsumedCapacity { return &in.ReturnConsumedCapacity },
+		l.dynamoAPI.Scan,
+		func(out *dynamodb.ScanOutput) []types.ConsumedCapacity {
+			return capacityOf(params.TableName, out.ConsumedCapacity)
+		})
+}
+
+func (l *limitedAPI) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return limited(ctx, l, true, []string{aws.ToString(params.TableName)}, params, optFns,
+		func(in *dynamodb.PutItemInput) *types.ReturnConsumedCapacity { return &in.ReturnConsumedCapacity },
+		l.dynamoAPI.PutItem,
+		func(out *dynamodb.PutItemOutput) []types.ConsumedCapacity {
+			return capacityOf(params.TableName, out.ConsumedCapacity)
+		})
+}
+
+func (l *limitedAPI) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return limited(ctx, l, true, []string{aws.ToString(params.TableName)}, params, optFns,
+		func(in *dynamodb.UpdateItemInput) *types.ReturnConsumedCapacity { return &in.ReturnConsumedCapacity },
+		l.dynamoAPI.UpdateItem,
+		func(out *dynamodb.UpdateItemOutput) []types.ConsumedCapacity {
+			return capacityOf(params.TableName, out.ConsumedCapacity)
+		})
+}
+
+func (l *limitedAPI) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	return limited(ctx, l, true, []string{aws.ToString(params.TableName)}, params, optFns,
+		func(in *dynamodb.DeleteItemInput) *types.ReturnConsumedCapacity { return &in.ReturnConsumedCapacity },
+		l.dynamoAPI.DeleteItem,
+		func(out *dynamodb.DeleteItemOutput) []types.ConsumedCapacity {
+			return capacityOf(params.TableName, out.ConsumedCapacity)
+		})
+}
+
+func (l *limitedAPI) BatchGetItem(ctx context.Context, params *dynamodb.BatchGetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	return limited(ctx, l, false, slices.Collect(maps.Keys(params.RequestItems)), params, optFns,
+		func(in *dynamodb.BatchGetItemInput) *types.ReturnConsumedCapacity { return &in.ReturnConsumedCapacity },
+		l.dynamoAPI.BatchGetItem,
+		func(out *dynamodb.BatchGetItemOutput) []types.ConsumedCapacity { return out.ConsumedCapacity })
+}
+
+func (l *limitedAPI) BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	return limited(ctx, l, true, slices.Collect(maps.Keys(params.RequestItems)), params, optFns,
+		func(in *dynamodb.BatchWriteItemInput) *types.ReturnConsumedCapacity {
+			return &in.ReturnConsumedCapacity
+		},
+		l.dynamoAPI.BatchWriteItem,
+		func(out *dynamodb.BatchWriteItemOutput) []types.ConsumedCapacity { return out.ConsumedCapacity })
+}
+
+func (l *limitedAPI) TransactGetItems(ctx context.Context, params *dynamodb.TransactGetItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	var tables []string
+	for _, item := range params.TransactItems {
+		if item.Get != nil {
+			tables = append(tables, aws.ToString(item.Get.TableName))
+		}
+	}
+	return limited(ctx, l, false, tables, params, optFns,
+		func(in *dynamodb.TransactGetItemsInput) *types.ReturnConsumedCapacity {
+			return &in.ReturnConsumedCapacity
+		},
+		l.dynamoAPI.TransactGetItems,
+		func(out *dynamodb.TransactGetItemsOutput) []types.ConsumedCapacity { return out.ConsumedCapacity })
+}
+
+func (l *limitedAPI) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	var tables []string
+	for _, item := range params.TransactItems {
+		switch {
+		case item.Put != nil:
+			tables = append(tables, aws.ToString(item.Put.TableName))
+		case item.Update != nil:
+			tables = append(tables, aws.ToString(item.Update.TableName))
+		case item.Delete != nil:
+			tables = append(tables, aws.ToString(item.Delete.TableName))
+		case item.ConditionCheck != nil:
+			tables = append(tables, aws.ToString(item.ConditionCheck.TableName))
+		}
+	}
+	return limited(ctx, l, true, tables, params, optFns,
+		func(in *dynamodb.TransactWriteItemsInput) *types.ReturnConsumedCapacity {
+			return &in.ReturnConsumedCapacity
+		},
+		l.dynamoAPI.TransactWriteItems,
+		func(out *dynamodb.TransactWriteItemsOutput) []types.ConsumedCapacity { return out.ConsumedCapacity })
+}
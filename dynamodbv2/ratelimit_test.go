@@ -0,0 +1,202 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+// rateLimitedClient returns a client whose limiter sleeps by moving the fixed clock
+// forward, adding the time slept to slept.
+func rateLimitedClient(api dynamoAPI, now *time.Time, slept *time.Duration, tables ...DynamoTable) *Implementation {
+	i := &Implementation{clock: fixedClock(now)}
+	for _, table := range tables {
+		WithTable(table)(i)
+	}
+	i.client = &limitedAPI{dynamoAPI: api, client: i, sleep: func(_ context.Context, d time.Duration) error {
+		*now = now.Add(d)
+		*slept += d
+		return nil
+	}}
+	return i
+}
+
+// retriedDeletes sends deletes through the middlewares of their options and a retry
+// loop of maxAttempts, failing the first throttled attempts.
+type retriedDeletes struct {
+	*dynamoClientMock
+	maxAttempts int
+	throttled   int
+	attempts    int
+}
+
+func (r *retriedDeletes) DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	var options dynamodb.Options
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	stack := middleware.NewStack("DeleteItem", smithyhttp.NewStackRequest)
+	retry := middleware.FinalizeMiddlewareFunc("Retry", func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (out middleware.FinalizeOutput, metadata middleware.Metadata, err error) {
+		for attempt := 1; ; attempt++ {
+			out, metadata, err = next.HandleFinalize(ctx, in)
+			if err == nil || attempt == r.maxAttempts {
+				return out, metadata, err
+			}
+		}
+	})
+	if err := stack.Finalize.Add(retry, middleware.After); err != nil {
+		return nil, err
+	}
+	for _, fn := range options.APIOptions {
+		if err := fn(stack); err != nil {
+			return nil, err
+		}
+	}
+	send := middleware.HandlerFunc(func(context.Context, interface{}) (interface{}, middleware.Metadata, error) {
+		r.attempts++
+		if r.throttled > 0 {
+			r.throttled--
+			return nil, middleware.Metadata{}, &types.ProvisionedThroughputExceededException{Message: aws.String("slow down")}
+		}
+		return nil, middleware.Metadata{}, nil
+	})
+	if _, _, err := middleware.DecorateHandler(send, stack).Handle(ctx, params); err != nil {
+		return nil, err
+	}
+	return &dynamodb.DeleteItemOutput{}, nil
+}
+
+func TestRateLimitWaitsForWriteCapacity(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept time.Duration
+	var returnCapacity []types.ReturnConsumedCapacity
+	mock := &dynamoClientMock{
+		funcPutItem: func(_ context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			returnCapacity = append(returnCapacity, input.ReturnConsumedCapacity)
+			return &dynamodb.PutItemOutput{ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1)}}, nil
+		},
+		funcGetItem: func(_ context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			assert.Empty(t, input.ReturnConsumedCapacity)
+			return &dynamodb.GetItemOutput{Item: map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}}}, nil
+		},
+	}
+	client := rateLimitedClient(mock, &now, &slept, DynamoTable{TableName: "jobs", PartitionKeyField: "id", RateLimit: RateLimit{WriteUnits: 2}})
+
+	// The first two writes use the burst, the next three wait for half a second each.
+	for n := 0; n < 5; n++ {
+		assert.NoError(t, client.Save("jobs", map[string]string{"id": "1"}))
+	}
+	assert.InDelta(t, 1.5, slept.Seconds(), 0.02)
+	assert.Equal(t, []types.ReturnConsumedCapacity{"TOTAL", "TOTAL", "TOTAL", "TOTAL", "TOTAL"}, returnCapacity)
+
+	// Reads are not limited on this table.
+	slept = 0
+	var item map[string]string
+	for n := 0; n < 5; n++ {
+		assert.NoError(t, client.GetOne("jobs", "1", &item))
+	}
+	assert.Zero(t, slept)
+}
+
+func TestRateLimitUsesConsumedCapacity(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept time.Duration
+	mock := &dynamoClientMock{
+		funcQuery: func(_ context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			assert.Equal(t, types.ReturnConsumedCapacityTotal, input.ReturnConsumedCapacity)
+			return &dynamodb.QueryOutput{ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(10)}}, nil
+		},
+	}
+	client := rateLimitedClient(mock, &now, &slept, DynamoTable{TableName: "jobs", PartitionKeyField: "id", RateLimit: RateLimit{ReadUnits: 5}})
+
+	// The first query leaves the bucket five units in debt, so the second one waits
+	// until it holds a unit again.
+	var items []map[string]string
+	_, err := client.QueryKey("jobs", KeyQuery{PartitionKey: "1"}, &items)
+	assert.NoError(t, err)
+	assert.Zero(t, slept)
+	_, err = client.QueryKey("jobs", KeyQuery{PartitionKey: "1"}, &items)
+	assert.NoError(t, err)
+	assert.InDelta(t, 1.2, slept.Seconds(), 0.01)
+}
+
+func TestRateLimitSettlesBatchesPerTable(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept time.Duration
+	mock := &dynamoClientMock{
+		funcBatchWriteItem: func(_ context.Context, input *dynamodb.BatchWriteItemInput) (*dynamodb.BatchWriteItemOutput, error) {
+			assert.Equal(t, types.ReturnConsumedCapacityTotal, input.ReturnConsumedCapacity)
+			return &dynamodb.BatchWriteItemOutput{ConsumedCapacity: []types.ConsumedCapacity{
+				{TableName: aws.String("jobs"), CapacityUnits: aws.Float64(4)},
+				{TableName: aws.String("events"), CapacityUnits: aws.Float64(4)},
+			}}, nil
+		},
+	}
+	client := rateLimitedClient(mock, &now, &slept,
+		DynamoTable{TableName: "jobs", PartitionKeyField: "id", RateLimit: RateLimit{WriteUnits: 2}},
+		DynamoTable{TableName: "events", PartitionKeyField: "id"},
+	)
+	limiter := client.limiter("jobs", true)
+
+	input := &dynamodb.BatchWriteItemInput{RequestItems: map[string][]types.WriteRequest{
+		"jobs":   {{PutRequest: &types.PutRequest{}}},
+		"events": {{PutRequest: &types.PutRequest{}}},
+	}}
+	_, err := client.client.BatchWriteItem(context.Background(), input)
+	assert.NoError(t, err)
+	assert.Empty(t, input.ReturnConsumedCapacity, "the input of the caller is left untouched")
+	assert.InDelta(t, -2, limiter.tokens, 0.001)
+	assert.Nil(t, client.limiter("events", true))
+}
+
+func TestRateLimitSlowsDownOnThrottling(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var slept time.Duration
+	api := &retriedDeletes{dynamoClientMock: &dynamoClientMock{}, maxAttempts: 3, throttled: 2}
+	client := rateLimitedClient(api, &now, &slept, DynamoTable{TableName: "jobs", PartitionKeyField: "id", RateLimit: RateLimit{WriteUnits: 10}})
+	limiter := client.limiter("jobs", true)
+
+	// Both throttled attempts slow down, even though the retry succeeds.
+	assert.NoError(t, client.Delete("jobs", "1"))
+	assert.Equal(t, 3, api.attempts)
+	assert.Equal(t, 2.5, limiter.rate)
+
+	api.throttled = 30
+	for n := 0; n < 10; n++ {
+		assert.Error(t, client.Delete("jobs", "1"))
+	}
+	assert.Equal(t, 1.0, limiter.rate)
+
+	// Without throttling the rate climbs back to the limit.
+	now = now.Add(20 * time.Second)
+	limiter.refill(now)
+	assert.Equal(t, 10.0, limiter.rate)
+}
+
+func TestRegisterTableReplacesRateLimit(t *testing.T) {
+	client := &Implementation{}
+	WithTable(DynamoTable{TableName: "jobs", PartitionKeyField: "id", RateLimit: RateLimit{ReadUnits: 5}})(client)
+	assert.NotNil(t, client.limiter("jobs", false))
+	assert.Nil(t, client.limiter("jobs", true))
+
+	// The same limit keeps the limiter and the rate it adapted to.
+	limiter := client.limiter("jobs", false)
+	limiter.throttled()
+	WithTable(DynamoTable{TableName: "jobs", PartitionKeyField: "id", RateLimit: RateLimit{ReadUnits: 5}, MaxPageSize: 10})(client)
+	assert.Same(t, limiter, client.limiter("jobs", false))
+	assert.Equal(t, 2.5, limiter.rate)
+
+	WithTable(DynamoTable{TableName: "jobs", PartitionKeyField: "id", RateLimit: RateLimit{ReadUnits: 8}})(client)
+	assert.NotSame(t, limiter, client.limiter("jobs", false))
+	assert.Equal(t, 8.0, client.limiter("jobs", false).rate)
+
+	WithTable(DynamoTable{TableName: "jobs", PartitionKeyField: "id"})(client)
+	assert.Nil(t, client.limiter("jobs", false))
+}
@@ -0,0 +1,34 @@
+package dynamodb
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// QueryResult describes what a query or scan read, so callers can report its cost and
+// decide whether to keep reading.
+type QueryResult struct {
+	// Count is the number of items returned, after filters.
+	Count int32
+	// ScannedCount is the number of items evaluated before filters were applied.
+	ScannedCount int32
+	// ConsumedCapacity is the total of read capacity units consumed by every request.
+	ConsumedCapacity float64
+	// Pages is the number of DynamoDB requests made.
+	Pages int
+	// HasMore reports whether items were left unread.
+	HasMore bool
+	// Cursor resumes a query on its next page. It is empty when HasMore is false or
+	// the read cannot be resumed, as for scans.
+	Cursor string
+}
+
+// add accounts for one DynamoDB response.
+func (r *QueryResult) add(count int32, scannedCount int32, capacity *types.ConsumedCapacity) {
+	r.Pages++
+	r.Count += count
+	r.ScannedCount += scannedCount
+	if capacity != nil {
+		r.ConsumedCapacity += aws.ToFloat64(capacity.CapacityUnits)
+	}
+}
@@ -0,0 +1,207 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrStopScan can be returned by a scan callback to stop the scan without an error.
+var ErrStopScan = errors.New("dynamo: stop scan")
+
+// ScanOption customizes a scan.
+type ScanOption func(o *scanOptions)
+
+type scanOptions struct {
+	segments int
+	workers  int
+}
+
+// WithSegments splits the scan in the given number of segments, read in parallel.
+// Defaults to 1, a sequential scan.
+func WithSegments(segments int) ScanOption {
+	return func(o *scanOptions) {
+		o.segments = segments
+	}
+}
+
+// WithScanWorkers sets how many segments are read concurrently. Defaults to the
+// number of segments.
+func WithScanWorkers(workers int) ScanOption {
+	return func(o *scanOptions) {
+		o.workers = workers
+	}
+}
+
+func newScanOptions(opts []ScanOption) *scanOptions {
+	o := &scanOptions{segments: 1}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.segments < 1 {
+		o.segments = 1
+	}
+	if o.workers < 1 || o.workers > o.segments {
+		o.workers = o.segments
+	}
+	return o
+}
+
+// ScanPage is a page of items read from one segment of a scan.
+type ScanPage struct {
+	Segment int
+	Items   []map[string]types.AttributeValue
+}
+
+// Unmarshal decodes the items of the page into bindTo, which must point to a slice.
+func (p ScanPage) Unmarshal(bindTo interface{}) error {
+	return attributevalue.UnmarshalListOfMapsWithOptions(p.Items, bindTo, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+}
+
+// Scan reads the whole table and binds the items matching the filter and projection
+// of the expression to bindTo. An empty expression returns every item.
+func (i *Implementation) Scan(ctx context.Context, table string, filter expression.Expression, bindTo interface{}, opts ...ScanOption) (*QueryResult, error) {
+	var items []map[string]types.AttributeValue
+	result, err := i.ScanPages(ctx, table, filter, func(page ScanPage) error {
+		items = append(items, page.Items...)
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	err = attributevalue.UnmarshalListOfMapsWithOptions(items, bindTo, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ScanPages reads the table and calls fn with every page of items matching the
+// filter, so large tables are processed without holding them in memory. Segments are
+// read in parallel but fn is never called concurrently. Each request reads up to the
+// table MaxPageSize items. The scan stops at the first error returned by fn, which is
+// returned unless it is ErrStopScan. The result adds up the reads of every segment,
+// and after ErrStopScan its HasMore reports whether the table had items left to read.
+func (i *Implementation) ScanPages(ctx context.Context, table string, filter expression.Expression, fn func(page ScanPage) error, opts ...ScanOption) (*QueryResult, error) {
+	def, err := i.table(table)
+	if err != nil {
+		return nil, err
+	}
+	ops := newScanOptions(opts)
+	log.Printf("[DynamoDB] executing scan with %d segments", ops.segments)
+
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var mu sync.Mutex
+	result := &QueryResult{}
+	finished := 0
+	handle := func(segment int, output *dynamodb.ScanOutput) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if scanCtx.Err() != nil {
+			return scanCtx.Err()
+		}
+		result.add(output.Count, output.ScannedCount, output.ConsumedCapacity)
+		if output.LastEvaluatedKey == nil {
+			finished++
+		}
+		if len(output.Items) == 0 {
+			return nil
+		}
+		return fn(ScanPage{Segment: segment, Items: output.Items})
+	}
+
+	segments := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < ops.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for segment := range segments {
+				if err := i.scanSegment(scanCtx, def, filter, segment, ops.segments, handle); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+feed:
+	for segment := 0; segment < ops.segments; segment++ {
+		select {
+		case segments <- segment:
+		case <-scanCtx.Done():
+			break feed
+		}
+	}
+	close(segments)
+	wg.Wait()
+
+	if errors.Is(firstErr, ErrStopScan) {
+		// a stopped scan has more items unless every segment read its last page
+		result.HasMore = finished < ops.segments
+		return result, nil
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	log.Printf("[DynamoDB] consume Capacity: %v | total Page: %d | count: %d", result.ConsumedCapacity, result.Pages, result.Count)
+	return result, nil
+}
+
+// scanSegment reads every page of one segment of the table.
+func (i *Implementation) scanSegment(ctx context.Context, def DynamoTable, filter expression.Expression, segment int, totalSegments int, handle func(segment int, output *dynamodb.ScanOutput) error) error {
+	scanInput := &dynamodb.ScanInput{
+		TableName:                 aws.String(def.TableName),
+		ExpressionAttributeNames:  filter.Names(),
+		ExpressionAttributeValues: filter.Values(),
+		FilterExpression:          filter.Filter(),
+		ProjectionExpression:      filter.Projection(),
+		ReturnConsumedCapacity:    types.ReturnConsumedCapacityTotal,
+	}
+	if totalSegments > 1 {
+		scanInput.Segment = aws.Int32(int32(segment))
+		scanInput.TotalSegments = aws.Int32(int32(totalSegments))
+	}
+	applyLimits(&scanInput.Limit, def.MaxPageSize)
+
+	for {
+		output, err := i.client.Scan(ctx, scanInput)
+		if err != nil {
+			return fmt.Errorf("failed to scan items: %w", err)
+		}
+		// expired items count as filtered out
+		output.Items = def.live(output.Items, i.now())
+		output.Count = int32(len(output.Items))
+		if err := handle(segment, output); err != nil {
+			return err
+		}
+		if output.LastEvaluatedKey == nil {
+			return nil
+		}
+		scanInput.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
@@ -0,0 +1,158 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// segmentedScan returns two pages of two orders for every segment.
+func segmentedScan(inputs *[]*dynamodb.ScanInput, mu *sync.Mutex) func(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+	return func(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+		mu.Lock()
+		*inputs = append(*inputs, input)
+		mu.Unlock()
+		segment := strconv.Itoa(int(aws.ToInt32(input.Segment)))
+		if input.ExclusiveStartKey == nil {
+			return &dynamodb.ScanOutput{
+				Items:            []map[string]types.AttributeValue{orderItem(segment+"-1", "new"), orderItem(segment+"-2", "new")},
+				Count:            2,
+				ScannedCount:     3,
+				ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1)},
+				LastEvaluatedKey: map[string]types.AttributeValue{"order_id": &types.AttributeValueMemberS{Value: segment + "-2"}},
+			}, nil
+		}
+		return &dynamodb.ScanOutput{
+			Items:            []map[string]types.AttributeValue{orderItem(segment+"-3", "new"), orderItem(segment+"-4", "new")},
+			Count:            2,
+			ScannedCount:     2,
+			ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1)},
+		}, nil
+	}
+}
+
+func TestScanParallelSegments(t *testing.T) {
+	var mu sync.Mutex
+	var inputs []*dynamodb.ScanInput
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"orders": {TableName: "orders", PartitionKeyField: "order_id", MaxPageSize: 2}},
+		client:       &dynamoClientMock{funcScan: segmentedScan(&inputs, &mu)},
+	}
+
+	var orders []order
+	result, err := client.Scan(context.Background(), "orders", expression.Expression{}, &orders, WithSegments(3), WithScanWorkers(2))
+	assert.NoError(t, err)
+	assert.Len(t, orders, 12)
+	assert.Equal(t, &QueryResult{Count: 12, ScannedCount: 15, ConsumedCapacity: 6, Pages: 6}, result)
+	assert.Len(t, inputs, 6)
+	for _, input := range inputs {
+		assert.Equal(t, int32(3), *input.TotalSegments)
+		assert.Equal(t, int32(2), *input.Limit)
+	}
+}
+
+func TestScanPagesStops(t *testing.T) {
+	var mu sync.Mutex
+	var inputs []*dynamodb.ScanInput
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"orders": {TableName: "orders", PartitionKeyField: "order_id"}},
+		client:       &dynamoClientMock{funcScan: segmentedScan(&inputs, &mu)},
+	}
+
+	pages := 0
+	result, err := client.ScanPages(context.Background(), "orders", expression.Expression{}, func(page ScanPage) error {
+		pages++
+		return ErrStopScan
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.HasMore)
+	assert.Equal(t, 1, pages)
+	assert.Len(t, inputs, 1)
+	assert.Nil(t, inputs[0].TotalSegments)
+
+	// stopping on the last page leaves nothing to read
+	pages = 0
+	result, err = client.ScanPages(context.Background(), "orders", expression.Expression{}, func(page ScanPage) error {
+		pages++
+		if pages == 2 {
+			return ErrStopScan
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.False(t, result.HasMore)
+	assert.Equal(t, 2, pages)
+
+	failure := errors.New("failure")
+	_, err = client.ScanPages(context.Background(), "orders", expression.Expression{}, func(page ScanPage) error {
+		return failure
+	}, WithSegments(4))
+	assert.ErrorIs(t, err, failure)
+}
+
+func TestTableScanEach(t *testing.T) {
+	var mu sync.Mutex
+	var inputs []*dynamodb.ScanInput
+	client := &Implementation{client: &dynamoClientMock{funcScan: segmentedScan(&inputs, &mu)}}
+	orders, err := NewTable[order](client, DynamoTable{TableName: "orders", PartitionKeyField: "order_id"})
+	assert.NoError(t, err)
+
+	var ids []string
+	_, err = orders.ScanEach(context.Background(), expression.Expression{}, func(o order) error {
+		ids = append(ids, o.ID)
+		return nil
+	}, WithSegments(2))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"0-1", "0-2", "0-3", "0-4", "1-1", "1-2", "1-3", "1-4"}, ids)
+}
+
+func TestDynamoLocalDevelopmentScan(t *testing.T) {
+	type order struct {
+		ID     string `dynamo:"order_id" json:"order_id"`
+		Status string `dynamo:"status" json:"status"`
+		Total  int    `dynamo:"total" json:"total"`
+	}
+	client := NewLocalClient().WithTable(DynamoTable{TableName: "orders", PartitionKeyField: "order_id", MaxPageSize: 2})
+	for n := 1; n <= 5; n++ {
+		status := "new"
+		if n%2 == 0 {
+			status = "paid"
+		}
+		assert.NoError(t, client.Save("orders", order{ID: strconv.Itoa(n), Status: status, Total: n}))
+	}
+	filter, err := expression.NewBuilder().WithFilter(expression.Name("status").Equal(expression.Value("new"))).Build()
+	assert.NoError(t, err)
+
+	var c Client = client
+	var orders []order
+	result, err := c.Scan(context.Background(), "orders", filter, &orders)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), result.Count)
+	assert.ElementsMatch(t, []string{"1", "3", "5"}, []string{orders[0].ID, orders[1].ID, orders[2].ID})
+
+	var pages [][]order
+	result, err = c.ScanPages(context.Background(), "orders", expression.Expression{}, func(page ScanPage) error {
+		var items []order
+		if err := page.Unmarshal(&items); err != nil {
+			return err
+		}
+		pages = append(pages, items)
+		if len(pages) == 2 {
+			return ErrStopScan
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, result.HasMore)
+	assert.Equal(t, int32(4), result.Count)
+	assert.Len(t, pages, 2)
+	assert.Len(t, pages[1], 2)
+}
@@ -0,0 +1,338 @@
+package dynamodb
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrInvalidModel is returned when a table is registered with a Model whose tags do
+// not describe a valid schema or disagree with the table definition.
+var ErrInvalidModel = errors.New("dynamo: invalid model")
+
+// Tag options that declare the schema of a table on its Model:
+//
+//	ID        string    `dynamo:"id,hash"`
+//	CreatedAt time.Time `dynamo:"created_at,range"`
+//	Email     string    `dynamo:"email,gsi-hash=by-email"`
+//	Score     int       `dynamo:"score,lsi-range=by-score"`
+//	ExpiresAt int64     `dynamo:"expires_at,ttl"`
+//	Version   int64     `dynamo:"version,version"`
+const (
+	tagHash     = "hash"
+	tagRange    = "range"
+	tagGSIHash  = "gsi-hash"
+	tagGSIRange = "gsi-range"
+	tagLSIRange = "lsi-range"
+	tagTTL      = "ttl"
+	tagVersion  = "version"
+)
+
+// modelField is an attribute of a model, with the tag options that follow its name.
+type modelField struct {
+	typ     reflect.Type
+	options []string
+}
+
+// model is the set of attributes of a model type, by attribute name.
+type model struct {
+	typ    reflect.Type
+	fields map[string]modelField
+}
+
+// withModel fills the definition from the tags of its Model, when it has one. Fields
+// set on the definition must agree with the model.
+func (d DynamoTable) withModel() (DynamoTable, error) {
+	if d.Model == nil {
+		return d, nil
+	}
+	m, err := newModel(d.Model)
+	if err != nil {
+		return d, err
+	}
+	if err := m.infer(&d); err != nil {
+		return d, err
+	}
+	return d, m.check(&d)
+}
+
+func newModel(value interface{}) (model, error) {
+	t := reflect.TypeOf(value)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return model{}, fmt.Errorf("%w: %s is not a struct", ErrInvalidModel, t)
+	}
+	m := model{typ: t, fields: make(map[string]modelField)}
+	m.add(t)
+	return m, nil
+}
+
+// add collects the attributes of a struct the way the attributevalue package
+// marshals it: untagged embedded structs are flattened and outer fields win.
+func (m model) add(t reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get(Tagkey), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && tag[0] == "" && ft.Kind() == reflect.Struct {
+			embedded = append(embedded, ft)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := m.fields[name]; !ok {
+			m.fields[name] = modelField{typ: ft, options: tag[1:]}
+		}
+	}
+	for _, t := range embedded {
+		m.add(t)
+	}
+}
+
+// infer sets the key, index, TTL and version fields declared by the model tags.
+func (m model) infer(d *DynamoTable) error {
+	var hash, sortKey, ttl, version string
+	global := map[string]*Index{}
+	local := map[string]*Index{}
+	var names []string
+	for name := range m.fields {
+		names = append(names, name)
+	}
+	// Sorted so that errors name the same fields on every run.
+	sort.Strings(names)
+	for _, name := range names {
+		for _, option := range m.fields[name].options {
+			option, index, _ := strings.Cut(option, "=")
+			var err error
+			switch option {
+			case tagHash:
+				err = m.tag(&hash, name, "hash key")
+			case tagRange:
+				err = m.tag(&sortKey, name, "range key")
+			case tagTTL:
+				err = m.tag(&ttl, name, "ttl attribute")
+			case tagVersion:
+				err = m.tag(&version, name, "version attribute")
+			case tagGSIHash, tagGSIRange, tagLSIRange:
+				if index == "" {
+					return fmt.Errorf("%w: %s: %s of %s needs an index name", ErrInvalidModel, m.typ, option, name)
+				}
+				indexes := global
+				if option == tagLSIRange {
+					indexes = local
+				}
+				if indexes[index] == nil {
+					indexes[index] = &Index{Name: index}
+				}
+				if option == tagGSIHash {
+					err = m.tag(&indexes[index].PartitionKeyField, name, "hash key of index "+index)
+				} else {
+					err = m.tag(&indexes[index].SortKeyField, name, "range key of index "+index)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := m.merge(&d.PartitionKeyField, hash, "partition key"); err != nil {
+		return err
+	}
+	if err := m.merge(&d.SortKeyField, sortKey, "sort key"); err != nil {
+		return err
+	}
+	if err := m.merge(&d.TTLField, ttl, "ttl"); err != nil {
+		return err
+	}
+	if err := m.merge(&d.VersionField, version, "version"); err != nil {
+		return err
+	}
+	var err error
+	if d.GlobalIndexes, err = m.mergeIndexes(d.GlobalIndexes, global); err != nil {
+		return err
+	}
+	if d.LocalIndexes, err = m.mergeIndexes(d.LocalIndexes, local); err != nil {
+		return err
+	}
+	for name, index := range global {
+		if index.PartitionKeyField == "" {
+			return fmt.Errorf("%w: %s: global index %s has no hash key", ErrInvalidModel, m.typ, name)
+		}
+		if local[name] != nil {
+			return fmt.Errorf("%w: %s: index %s is tagged as both global and local", ErrInvalidModel, m.typ, name)
+		}
+	}
+	return nil
+}
+
+// tag records the attribute tagged for a role, failing when two attributes claim it.
+func (m model) tag(field *string, name, role string) error {
+	if *field != "" {
+		return fmt.Errorf("%w: %s: %s and %s are both tagged as %s", ErrInvalidModel, m.typ, *field, name, role)
+	}
+	*field = name
+	return nil
+}
+
+// merge fills a definition field from the model, failing when both are set and differ.
+func (m model) merge(field *string, inferred, property string) error {
+	if inferred == "" || *field == inferred {
+		return nil
+	}
+	if *field != "" {
+		return fmt.Errorf("%w: %s: %s is %s in the table definition but %s in the model", ErrInvalidModel, m.typ, property, *field, inferred)
+	}
+	*field = inferred
+	return nil
+}
+
+// mergeIndexes adds the indexes declared by the model to the ones of the definition,
+// keeping the projection of indexes declared in both.
+func (m model) mergeIndexes(defined []Index, inferred map[string]*Index) ([]Index, error) {
+	merged := append([]Index(nil), defined...)
+	var names []string
+	for name := range inferred {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		index := inferred[name]
+		found := false
+		for i := range merged {
+			if merged[i].Name != name {
+				continue
+			}
+			found = true
+			if err := m.merge(&merged[i].PartitionKeyField, index.PartitionKeyField, "partition key of index "+name); err != nil {
+				return nil, err
+			}
+			if err := m.merge(&merged[i].SortKeyField, index.SortKeyField, "sort key of index "+name); err != nil {
+				return nil, err
+			}
+		}
+		if !found {
+			merged = append(merged, *index)
+		}
+	}
+	return merged, nil
+}
+
+// check verifies that every attribute named by the definition is in the model with a
+// matching type, and fills the key types the definition leaves unset.
+func (m model) check(d *DynamoTable) error {
+	if err := m.key(d.PartitionKeyField, &d.PartitionKeyType); err != nil {
+		return err
+	}
+	if err := m.key(d.SortKeyField, &d.SortKeyType); err != nil {
+		return err
+	}
+	for i := range d.GlobalIndexes {
+		index := &d.GlobalIndexes[i]
+		if err := m.key(index.PartitionKeyField, &index.PartitionKeyType); err != nil {
+			return err
+		}
+		if err := m.key(index.SortKeyField, &index.SortKeyType); err != nil {
+			return err
+		}
+	}
+	for i := range d.LocalIndexes {
+		if err := m.key(d.LocalIndexes[i].SortKeyField, &d.LocalIndexes[i].SortKeyType); err != nil {
+			return err
+		}
+	}
+	for _, field := range []string{d.TTLField, d.VersionField} {
+		if field == "" {
+			continue
+		}
+		if t, err := m.attributeType(field); err != nil {
+			return err
+		} else if t != KeyTypeNumber {
+			return fmt.Errorf("%w: %s: %s must be a number", ErrInvalidModel, m.typ, field)
+		}
+	}
+	for _, field := range []string{d.CreatedAtField, d.UpdatedAtField} {
+		if field == "" {
+			continue
+		}
+		if _, ok := m.fields[field]; !ok {
+			return fmt.Errorf("%w: %s has no attribute %s", ErrInvalidModel, m.typ, field)
+		}
+	}
+	return nil
+}
+
+// key checks a key attribute of the model, setting keyType when it is empty.
+func (m model) key(field string, keyType *KeyType) error {
+	if field == "" {
+		return nil
+	}
+	t, err := m.attributeType(field)
+	if err != nil {
+		return err
+	}
+	switch {
+	case t == "":
+		return fmt.Errorf("%w: %s: %s of type %s cannot be a key", ErrInvalidModel, m.typ, field, m.fields[field].typ)
+	case *keyType == "":
+		*keyType = t
+	case *keyType != t:
+		return fmt.Errorf("%w: %s: %s is declared as %s but marshals as %s", ErrInvalidModel, m.typ, field, *keyType, t)
+	}
+	return nil
+}
+
+// attributeType returns the scalar type an attribute of the model marshals to, or ""
+// when it is not a string, number or binary.
+func (m model) attributeType(name string) (KeyType, error) {
+	f, ok := m.fields[name]
+	if !ok {
+		return "", fmt.Errorf("%w: %s has no attribute %s", ErrInvalidModel, m.typ, name)
+	}
+	has := func(option string) bool {
+		for _, o := range f.options {
+			if o == option {
+				return true
+			}
+		}
+		return false
+	}
+	if has("string") {
+		return KeyTypeString, nil
+	}
+	if f.typ == reflect.TypeOf(time.Time{}) {
+		if has("unixtime") {
+			return KeyTypeNumber, nil
+		}
+		return KeyTypeString, nil
+	}
+	switch f.typ.Kind() {
+	case reflect.String:
+		return KeyTypeString, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return KeyTypeNumber, nil
+	case reflect.Slice:
+		if f.typ.Elem().Kind() == reflect.Uint8 {
+			return KeyTypeBinary, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,110 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+type audit struct {
+	CreatedAt time.Time `dynamo:"created_at,range" json:"created_at"`
+	Version   int64     `dynamo:"version,version" json:"version"`
+}
+
+type customer struct {
+	audit
+	ID        string `dynamo:"id,hash" json:"id"`
+	Email     string `dynamo:"email,gsi-hash=by-email" json:"email"`
+	Score     int    `dynamo:"score,lsi-range=by-score,gsi-range=by-email" json:"score"`
+	ExpiresAt int64  `dynamo:"expires_at,ttl,omitempty" json:"expires_at"`
+	Name      string `dynamo:"name" json:"name"`
+}
+
+func TestRegisterTableInfersSchemaFromModel(t *testing.T) {
+	client := NewImplementation(aws.Config{}, WithTable(DynamoTable{
+		TableName:     "customers",
+		Model:         &customer{},
+		GlobalIndexes: []Index{{Name: "by-email", Projection: ProjectionKeysOnly}},
+	}))
+
+	def, err := client.table("customers")
+	assert.NoError(t, err)
+	assert.Equal(t, "id", def.PartitionKeyField)
+	assert.Equal(t, KeyTypeString, def.PartitionKeyType)
+	assert.Equal(t, "created_at", def.SortKeyField)
+	assert.Equal(t, KeyTypeString, def.SortKeyType)
+	assert.Equal(t, "expires_at", def.TTLField)
+	assert.Equal(t, "version", def.VersionField)
+	assert.Equal(t, []Index{{
+		Name:              "by-email",
+		PartitionKeyField: "email",
+		PartitionKeyType:  KeyTypeString,
+		SortKeyField:      "score",
+		SortKeyType:       KeyTypeNumber,
+		Projection:        ProjectionKeysOnly,
+	}}, def.GlobalIndexes)
+	assert.Equal(t, []Index{{Name: "by-score", SortKeyField: "score", SortKeyType: KeyTypeNumber}}, def.LocalIndexes)
+}
+
+func TestRegisterTableRejectsInconsistentModels(t *testing.T) {
+	type twoHashes struct {
+		A string `dynamo:"a,hash"`
+		B string `dynamo:"b,hash"`
+	}
+	type boolKey struct {
+		A bool `dynamo:"a,hash"`
+	}
+	type stringTTL struct {
+		A string `dynamo:"a,hash"`
+		T string `dynamo:"t,ttl"`
+	}
+	type rangeOnlyIndex struct {
+		A string `dynamo:"a,hash"`
+		B string `dynamo:"b,gsi-range=by-b"`
+	}
+	type unnamedIndex struct {
+		A string `dynamo:"a,hash"`
+		B string `dynamo:"b,gsi-hash"`
+	}
+	type numberKey struct {
+		A int `dynamo:"a,hash"`
+	}
+	tests := map[string]DynamoTable{
+		"not a struct":          {Model: "customer"},
+		"two hash keys":         {Model: twoHashes{}},
+		"key of invalid type":   {Model: boolKey{}},
+		"ttl not a number":      {Model: stringTTL{}},
+		"index without hash":    {Model: rangeOnlyIndex{}},
+		"index without name":    {Model: unnamedIndex{}},
+		"conflicting key field": {Model: numberKey{}, PartitionKeyField: "id"},
+		"conflicting key type":  {Model: numberKey{}, PartitionKeyType: KeyTypeString},
+		"missing sort key":      {Model: numberKey{}, SortKeyField: "created_at"},
+		"missing index key":     {Model: numberKey{}, LocalIndexes: []Index{{Name: "by-b", SortKeyField: "b"}}},
+	}
+	for name, def := range tests {
+		t.Run(name, func(t *testing.T) {
+			def.TableName = "broken"
+			err := NewImplementation(aws.Config{}).RegisterTable(def)
+			assert.ErrorIs(t, err, ErrInvalidModel)
+		})
+	}
+
+	assert.PanicsWithError(t, "dynamo: invalid model: dynamodb.twoHashes: a and b are both tagged as hash key", func() {
+		NewLocalClient().WithTable(DynamoTable{TableName: "broken", Model: twoHashes{}})
+	})
+}
+
+func TestDynamoLocalDevelopmentModelTable(t *testing.T) {
+	created := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	client := NewLocalClient().WithTable(DynamoTable{TableName: "customers", Model: customer{}})
+
+	err := client.SaveWithContext(context.Background(), "customers", &customer{ID: "1", audit: audit{CreatedAt: created}, Name: "Ada"})
+	assert.NoError(t, err)
+	var c customer
+	assert.NoError(t, client.GetOneWithSort("customers", "1", created.Format(time.RFC3339Nano), &c))
+	assert.Equal(t, "Ada", c.Name)
+	assert.Equal(t, int64(1), c.Version)
+}
@@ -1,13 +1,59 @@
 package dynamodb
 
-import "github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+import (
+	"context"
+	"iter"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
 
 type Client interface {
-	Save(table string, item interface{}) error
+	Save(table string, item interface{}, opts ...WriteOption) error
 	GetOne(table string, partitionKey string, bindTo interface{}) error
 	GetOneWithSort(table string, partitionKey string, sortKey string, bindTo interface{}) error
 	QueryOne(table string, partitionKey string, limit int32, bindTo interface{}) error
-	BatchGetWithSort(values map[string]interface{}) error
+	BatchGetWithSort(request BatchGetRequest, opts ...BatchOption) error
 	QueryExpression(table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error
 	QueryGSI(table string, globalIndex string, query expression.Expression, customLimit int32, pageDesired int32, bindTo interface{}) error
+	QueryExpressionResult(table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error)
+	QueryGSIResult(table string, globalIndex string, query expression.Expression, customLimit int32, pageDesired int32, bindTo interface{}) (*QueryResult, error)
+	QueryExpressionPage(table string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error)
+	QueryGSIPage(table string, globalIndex string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error)
+	QueryKey(table string, query KeyQuery, bindTo interface{}) (*QueryResult, error)
+	Delete(table string, partitionKey string, opts ...WriteOption) error
+	DeleteWithSort(table string, partitionKey string, sortKey string, opts ...WriteOption) error
+	Update(table string, partitionKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error
+	UpdateWithSort(table string, partitionKey string, sortKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error
+	ContextClient
+}
+
+// ContextClient is the context-first variant of Client. The given context is
+// propagated to every DynamoDB request, so deadlines and cancellation stop
+// in-flight operations.
+type ContextClient interface {
+	SaveWithContext(ctx context.Context, table string, item interface{}, opts ...WriteOption) error
+	GetOneWithContext(ctx context.Context, table string, partitionKey string, bindTo interface{}) error
+	GetOneWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, bindTo interface{}) error
+	QueryOneWithContext(ctx context.Context, table string, partitionKey string, limit int32, bindTo interface{}) error
+	BatchGetWithSortWithContext(ctx context.Context, request BatchGetRequest, opts ...BatchOption) error
+	QueryExpressionWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) error
+	QueryGSIWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, customLimit int32, pageDesired int32, bindTo interface{}) error
+	QueryExpressionResultWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, pageNumber int32, bindTo interface{}) (*QueryResult, error)
+	QueryGSIResultWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, customLimit int32, pageDesired int32, bindTo interface{}) (*QueryResult, error)
+	QueryExpressionPageWithContext(ctx context.Context, table string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error)
+	QueryGSIPageWithContext(ctx context.Context, table string, globalIndex string, query expression.Expression, pageSize int32, cursor string, bindTo interface{}) (*QueryResult, error)
+	QueryKeyWithContext(ctx context.Context, table string, query KeyQuery, bindTo interface{}) (*QueryResult, error)
+	DeleteWithContext(ctx context.Context, table string, partitionKey string, opts ...WriteOption) error
+	DeleteWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, opts ...WriteOption) error
+	UpdateWithContext(ctx context.Context, table string, partitionKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error
+	UpdateWithSortWithContext(ctx context.Context, table string, partitionKey string, sortKey string, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error
+	GetByKey(ctx context.Context, table string, key Key, bindTo interface{}) error
+	QueryOneByKey(ctx context.Context, table string, partitionKey interface{}, limit int32, bindTo interface{}) error
+	DeleteByKey(ctx context.Context, table string, key Key, opts ...WriteOption) error
+	UpdateByKey(ctx context.Context, table string, key Key, update expression.UpdateBuilder, bindTo interface{}, opts ...WriteOption) error
+	Scan(ctx context.Context, table string, filter expression.Expression, bindTo interface{}, opts ...ScanOption) (*QueryResult, error)
+	ScanPages(ctx context.Context, table string, filter expression.Expression, fn func(page ScanPage) error, opts ...ScanOption) (*QueryResult, error)
+	QueryItems(ctx context.Context, table string, query expression.Expression) iter.Seq2[Item, error]
+	QueryGSIItems(ctx context.Context, table string, globalIndex string, query expression.Expression) iter.Seq2[Item, error]
+	ScanItems(ctx context.Context, table string, filter expression.Expression) iter.Seq2[Item, error]
 }
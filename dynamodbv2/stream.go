@@ -0,0 +1,583 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+)
+
+// streamsAPI is the subset of the DynamoDB Streams SDK client used by StreamProcessor.
+type streamsAPI interface {
+	DescribeStream(ctx context.Context, params *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error)
+	GetShardIterator(ctx context.Context, params *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error)
+}
+
+// errLeaseLost is returned when another worker took over the lease of a shard.
+var errLeaseLost = errors.New("dynamo: shard lease lost")
+
+// StreamRecord is a change read from the stream of a table. EventName is INSERT,
+// MODIFY or REMOVE, and the images present depend on the stream view type.
+type StreamRecord struct {
+	EventID        string
+	EventName      string
+	SequenceNumber string
+	ShardID        string
+	CreatedAt      time.Time
+	Keys           map[string]types.AttributeValue
+	OldImage       map[string]types.AttributeValue
+	NewImage       map[string]types.AttributeValue
+}
+
+// UnmarshalKeys decodes the key attributes of the changed item into bindTo.
+func (r StreamRecord) UnmarshalKeys(bindTo interface{}) error {
+	return unmarshalItem(r.Keys, bindTo)
+}
+
+// UnmarshalOld decodes the item as it was before the change into bindTo, or returns
+// ErrNotFound when the record has no old image.
+func (r StreamRecord) UnmarshalOld(bindTo interface{}) error {
+	if r.OldImage == nil {
+		return ErrNotFound
+	}
+	return unmarshalItem(r.OldImage, bindTo)
+}
+
+// UnmarshalNew decodes the item as it is after the change into bindTo, or returns
+// ErrNotFound when the record has no new image.
+func (r StreamRecord) UnmarshalNew(bindTo interface{}) error {
+	if r.NewImage == nil {
+		return ErrNotFound
+	}
+	return unmarshalItem(r.NewImage, bindTo)
+}
+
+// StreamHandler processes a batch of records of one shard, in stream order. When it
+// returns an error the batch is not checkpointed and is delivered again.
+type StreamHandler func(ctx context.Context, records []StreamRecord) error
+
+// StreamOption customizes a StreamProcessor.
+type StreamOption func(o *streamOptions)
+
+type streamOptions struct {
+	workerID          string
+	leaseDuration     time.Duration
+	pollInterval      time.Duration
+	discoveryInterval time.Duration
+	batchSize         int32
+	maxShards         int
+	startAtLatest     bool
+}
+
+// WithWorkerID sets the name the processor holds its leases under. It must be unique
+// among the processors sharing a lease table. Defaults to the host name and process id.
+func WithWorkerID(id string) StreamOption {
+	return func(o *streamOptions) {
+		o.workerID = id
+	}
+}
+
+// WithLeaseDuration sets how long a shard stays owned by a worker that stopped
+// renewing its lease. Defaults to 30 seconds.
+func WithLeaseDuration(duration time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.leaseDuration = duration
+	}
+}
+
+// WithStreamPollInterval sets how long a shard waits for new records after reading
+// an empty batch. Defaults to 1 second.
+func WithStreamPollInterval(interval time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.pollInterval = interval
+	}
+}
+
+// WithShardDiscoveryInterval sets how often the shards of the stream are listed to
+// pick up new and released shards. Defaults to 10 seconds.
+func WithShardDiscoveryInterval(interval time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.discoveryInterval = interval
+	}
+}
+
+// WithStreamBatchSize sets the maximum number of records passed to the handler at
+// once, up to 1000. Defaults to 1000.
+func WithStreamBatchSize(size int32) StreamOption {
+	return func(o *streamOptions) {
+		o.batchSize = size
+	}
+}
+
+// WithMaxShards limits the shards a processor owns at the same time, so that the
+// other processors sharing the lease table take the rest. Defaults to no limit.
+func WithMaxShards(n int) StreamOption {
+	return func(o *streamOptions) {
+		o.maxShards = n
+	}
+}
+
+// WithStartAtLatest skips the changes made before the processor started. Shards that
+// were never processed and are open when the processor starts are read from their
+// latest record, and closed ones are marked finished without being read. The children
+// of shards that were read always start at their oldest record so that no change is
+// skipped.
+func WithStartAtLatest() StreamOption {
+	return func(o *streamOptions) {
+		o.startAtLatest = true
+	}
+}
+
+func newStreamOptions(opts []StreamOption) *streamOptions {
+	host, _ := os.Hostname()
+	o := &streamOptions{
+		workerID:          fmt.Sprintf("%s-%d", host, os.Getpid()),
+		leaseDuration:     30 * time.Second,
+		pollInterval:      time.Second,
+		discoveryInterval: 10 * time.Second,
+		batchSize:         1000,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Attributes of the items of a lease table.
+const (
+	leaseShardField      = "shard_id"
+	leaseOwnerField      = "owner"
+	leaseExpiresField    = "lease_expires"
+	leaseCheckpointField = "checkpoint"
+	leaseFinishedField   = "finished"
+	leaseSkippedField    = "skipped"
+)
+
+// LeaseTable returns the definition of a lease table for StreamProcessor, to be
+// registered on the client and created with ProvisionTables.
+func LeaseTable(name string) DynamoTable {
+	return DynamoTable{TableName: name, PartitionKeyField: leaseShardField}
+}
+
+// lease is the item of a lease table tracking the progress of one shard. Expires is
+// in epoch milliseconds. Skipped marks a shard finished by WithStartAtLatest without
+// reading it.
+type lease struct {
+	ShardID    string `dynamo:"shard_id"`
+	Owner      string `dynamo:"owner"`
+	Expires    int64  `dynamo:"lease_expires"`
+	Checkpoint string `dynamo:"checkpoint"`
+	Finished   bool   `dynamo:"finished"`
+	Skipped    bool   `dynamo:"skipped"`
+}
+
+// StreamProcessor consumes the stream of a table. Shards are leased in a lease table
+// so that several processors, in one or many instances, share them without reading a
+// shard twice, and the sequence number of the last processed record is checkpointed
+// so that processing resumes where it stopped. A child shard created by a split is
+// only read once its parent is finished, which keeps the changes of an item in order.
+type StreamProcessor struct {
+	client     *Implementation
+	table      string
+	leaseTable string
+	handler    StreamHandler
+	ops        *streamOptions
+}
+
+// NewStreamProcessor returns a processor that passes the changes of table to handler.
+// Streams must be enabled on the table and leaseTable must be registered on the
+// client, see LeaseTable.
+func (i *Implementation) NewStreamProcessor(table, leaseTable string, handler StreamHandler, opts ...StreamOption) *StreamProcessor {
+	return &StreamProcessor{
+		client:     i,
+		table:      table,
+		leaseTable: leaseTable,
+		handler:    handler,
+		ops:        newStreamOptions(opts),
+	}
+}
+
+// Run processes the stream until ctx is done, then releases its leases and returns
+// ctx.Err(). It returns early when the tables are not registered or the table has no
+// stream. Failures to read the stream or the lease table are logged and retried on
+// the next discovery, waiting longer after each consecutive failure.
+func (p *StreamProcessor) Run(ctx context.Context) error {
+	def, err := p.client.table(p.table)
+	if err != nil {
+		return err
+	}
+	if _, err := p.client.table(p.leaseTable); err != nil {
+		return err
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	active := make(map[string]bool)
+	var streamARN string
+	// startup holds the shards listed when the processor started, the only ones
+	// WithStartAtLatest applies to
+	var startup map[string]bool
+	discover := func() error {
+		if streamARN == "" {
+			arn, err := p.streamARN(ctx, def)
+			if err != nil {
+				return err
+			}
+			streamARN = arn
+		}
+		shards, err := p.shards(ctx, streamARN)
+		if err != nil {
+			return err
+		}
+		leases, err := p.leases(ctx)
+		if err != nil {
+			return err
+		}
+		open := make(map[string]bool, len(shards))
+		for _, shard := range shards {
+			open[aws.ToString(shard.ShardId)] = true
+		}
+		if startup == nil {
+			startup = open
+		}
+		for _, shard := range shards {
+			shardID := aws.ToString(shard.ShardId)
+			mu.Lock()
+			owned := len(active)
+			busy := active[shardID]
+			mu.Unlock()
+			if busy || leases[shardID].Finished || (p.ops.maxShards > 0 && owned >= p.ops.maxShards) {
+				continue
+			}
+			// a shard split closes the parent, whose records come before the child's
+			parent := aws.ToString(shard.ParentShardId)
+			if open[parent] && !leases[parent].Finished {
+				continue
+			}
+			l, acquired, err := p.acquire(ctx, shardID)
+			if err != nil {
+				return err
+			}
+			if !acquired {
+				continue
+			}
+			// the children of a shard that was read start at their oldest record, the
+			// others skip the changes made before the processor started
+			parentLease, hasParent := leases[parent]
+			latest := p.ops.startAtLatest && startup[shardID] && l.Checkpoint == "" && (!hasParent || parentLease.Skipped)
+			if latest && shard.SequenceNumberRange != nil && shard.SequenceNumberRange.EndingSequenceNumber != nil {
+				// a closed shard has no latest record to start at
+				l.Finished, l.Skipped = true, true
+				if err := p.renew(ctx, l, 0); err != nil {
+					return err
+				}
+				leases[shardID] = l
+				continue
+			}
+			mu.Lock()
+			active[shardID] = true
+			mu.Unlock()
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				p.consume(ctx, streamARN, l, latest)
+				mu.Lock()
+				delete(active, shardID)
+				mu.Unlock()
+			}()
+		}
+		return nil
+	}
+
+	failures := 0
+	for {
+		wait := p.ops.discoveryInterval
+		if err := discover(); errors.Is(err, errNoStream) {
+			return err
+		} else if err != nil && ctx.Err() == nil {
+			failures++
+			wait <<= min(failures-1, maxDiscoveryBackoff)
+			log.Printf("[DynamoDB] failed to discover shards of %s, retrying in %s: %s", p.table, wait, err)
+		} else {
+			failures = 0
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// maxDiscoveryBackoff caps the doublings of the discovery interval after failures.
+const maxDiscoveryBackoff = 3
+
+// errNoStream is returned by Run when streams are not enabled on the table.
+var errNoStream = errors.New("dynamo: table has no stream")
+
+// streamARN returns the latest stream of the table.
+func (p *StreamProcessor) streamARN(ctx context.Context, def DynamoTable) (string, error) {
+	out, err := p.client.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(def.TableName)})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe table: %w", err)
+	}
+	if out.Table == nil || out.Table.LatestStreamArn == nil {
+		return "", fmt.Errorf("%w: %s", errNoStream, def.TableName)
+	}
+	return *out.Table.LatestStreamArn, nil
+}
+
+// shards lists the shards of the stream in the order DynamoDB returns them, parents
+// before their children.
+func (p *StreamProcessor) shards(ctx context.Context, streamARN string) ([]streamtypes.Shard, error) {
+	var shards []streamtypes.Shard
+	input := &dynamodbstreams.DescribeStreamInput{StreamArn: aws.String(streamARN)}
+	for {
+		out, err := p.client.streams.DescribeStream(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stream: %w", err)
+		}
+		shards = append(shards, out.StreamDescription.Shards...)
+		if out.StreamDescription.LastEvaluatedShardId == nil {
+			return shards, nil
+		}
+		input.ExclusiveStartShardId = out.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// leases reads every lease of the lease table by shard id.
+func (p *StreamProcessor) leases(ctx context.Context) (map[string]lease, error) {
+	def, err := p.client.table(p.leaseTable)
+	if err != nil {
+		return nil, err
+	}
+	leases := make(map[string]lease)
+	input := &dynamodb.ScanInput{TableName: aws.String(def.TableName), ConsistentRead: aws.Bool(true)}
+	for {
+		out, err := p.client.client.Scan(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read leases: %w", err)
+		}
+		var page []lease
+		if err := attributevalue.UnmarshalListOfMapsWithOptions(out.Items, &page, func(options *attributevalue.DecoderOptions) {
+			options.TagKey = Tagkey
+		}); err != nil {
+			return nil, err
+		}
+		for _, l := range page {
+			leases[l.ShardID] = l
+		}
+		if out.LastEvaluatedKey == nil {
+			return leases, nil
+		}
+		input.ExclusiveStartKey = out.LastEvaluatedKey
+	}
+}
+
+// acquire takes the lease of a shard when it is free, expired or already ours.
+func (p *StreamProcessor) acquire(ctx context.Context, shardID string) (lease, bool, error) {
+	now := p.client.now().UnixMilli()
+	condition := expression.AttributeNotExists(expression.Name(leaseOwnerField)).
+		Or(expression.Name(leaseOwnerField).Equal(expression.Value(p.ops.workerID))).
+		Or(expression.Name(leaseExpiresField).LessThan(expression.Value(now)))
+	condition = condition.And(expression.AttributeNotExists(expression.Name(leaseFinishedField)).
+		Or(expression.Name(leaseFinishedField).Equal(expression.Value(false))))
+	update := expression.Set(expression.Name(leaseOwnerField), expression.Value(p.ops.workerID)).
+		Set(expression.Name(leaseExpiresField), expression.Value(now+p.ops.leaseDuration.Milliseconds()))
+	out, err := p.updateLease(ctx, shardID, update, condition)
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return lease{}, false, nil
+		}
+		return lease{}, false, err
+	}
+	var l lease
+	err = attributevalue.UnmarshalMapWithOptions(out.Attributes, &l, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+	return l, err == nil, err
+}
+
+// renew extends the lease of a shard, storing the checkpoint and whether the shard is
+// finished. It returns errLeaseLost when another worker owns the shard.
+func (p *StreamProcessor) renew(ctx context.Context, l lease, expires int64) error {
+	update := expression.Set(expression.Name(leaseExpiresField), expression.Value(expires)).
+		Set(expression.Name(leaseFinishedField), expression.Value(l.Finished))
+	if l.Checkpoint != "" {
+		update = update.Set(expression.Name(leaseCheckpointField), expression.Value(l.Checkpoint))
+	}
+	if l.Skipped {
+		update = update.Set(expression.Name(leaseSkippedField), expression.Value(true))
+	}
+	condition := expression.Name(leaseOwnerField).Equal(expression.Value(p.ops.workerID))
+	_, err := p.updateLease(ctx, l.ShardID, update, condition)
+	var conditionErr *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionErr) {
+		return errLeaseLost
+	}
+	return err
+}
+
+func (p *StreamProcessor) updateLease(ctx context.Context, shardID string, update expression.UpdateBuilder, condition expression.ConditionBuilder) (*dynamodb.UpdateItemOutput, error) {
+	def, err := p.client.table(p.leaseTable)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := expression.NewBuilder().WithUpdate(update).WithCondition(condition).Build()
+	if err != nil {
+		return nil, err
+	}
+	return p.client.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(def.TableName),
+		Key:                       map[string]types.AttributeValue{leaseShardField: &types.AttributeValueMemberS{Value: shardID}},
+		UpdateExpression:          expr.Update(),
+		ConditionExpression:       expr.Condition(),
+		ExpressionAttributeNames:  expr.Names(),
+		ExpressionAttributeValues: expr.Values(),
+		ReturnValues:              types.ReturnValueAllNew,
+	})
+}
+
+// consume reads a leased shard until it is finished, the lease is lost or ctx is done.
+// Failed batches are read again from the last checkpoint.
+func (p *StreamProcessor) consume(ctx context.Context, streamARN string, l lease, latest bool) {
+	defer func() {
+		// let other workers take the shard right away
+		if !l.Finished {
+			if err := p.renew(context.WithoutCancel(ctx), l, 0); err != nil && !errors.Is(err, errLeaseLost) {
+				log.Printf("[DynamoDB] failed to release lease of shard %s: %s", l.ShardID, err)
+			}
+		}
+	}()
+	renewEvery := p.ops.leaseDuration / 3
+	// at is the first record of a failed batch read from the latest record, where a
+	// retry starts instead of a new latest record, which would skip the batch
+	var at string
+	for ctx.Err() == nil {
+		iterator, err := p.iterator(ctx, streamARN, l, latest, at)
+		if err != nil {
+			log.Printf("[DynamoDB] failed to read shard %s: %s", l.ShardID, err)
+			return
+		}
+		renewed := p.client.now()
+		for iterator != nil {
+			out, err := p.client.streams.GetRecords(ctx, &dynamodbstreams.GetRecordsInput{
+				ShardIterator: iterator,
+				Limit:         aws.Int32(p.ops.batchSize),
+			})
+			if err != nil {
+				log.Printf("[DynamoDB] failed to read shard %s: %s", l.ShardID, err)
+				return
+			}
+			records, err := streamRecords(l.ShardID, out.Records)
+			if err == nil && len(records) > 0 {
+				err = p.handler(ctx, records)
+			}
+			if err != nil {
+				if latest && at == "" && len(records) > 0 {
+					at = records[0].SequenceNumber
+				}
+				log.Printf("[DynamoDB] failed to process shard %s, retrying from %q: %s", l.ShardID, l.Checkpoint, err)
+				if sleepContext(ctx, p.ops.pollInterval) != nil {
+					return
+				}
+				break
+			}
+			if len(records) > 0 {
+				l.Checkpoint = records[len(records)-1].SequenceNumber
+				latest = false
+			}
+			iterator = out.NextShardIterator
+			l.Finished = iterator == nil
+			if len(records) > 0 || l.Finished || p.client.now().Sub(renewed) >= renewEvery {
+				if err := p.renew(ctx, l, p.client.now().Add(p.ops.leaseDuration).UnixMilli()); err != nil {
+					log.Printf("[DynamoDB] stopped processing shard %s: %s", l.ShardID, err)
+					return
+				}
+				renewed = p.client.now()
+			}
+			if l.Finished {
+				log.Printf("[DynamoDB] finished processing shard %s", l.ShardID)
+				return
+			}
+			if len(records) == 0 && sleepContext(ctx, p.ops.pollInterval) != nil {
+				return
+			}
+		}
+	}
+}
+
+// iterator opens a shard after its checkpoint, or at the given record, or at its
+// start or latest record when it has neither.
+func (p *StreamProcessor) iterator(ctx context.Context, streamARN string, l lease, latest bool, at string) (*string, error) {
+	input := &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(streamARN),
+		ShardId:           aws.String(l.ShardID),
+		ShardIteratorType: streamtypes.ShardIteratorTypeTrimHorizon,
+	}
+	switch {
+	case l.Checkpoint != "":
+		input.ShardIteratorType = streamtypes.ShardIteratorTypeAfterSequenceNumber
+		input.SequenceNumber = aws.String(l.Checkpoint)
+	case at != "":
+		input.ShardIteratorType = streamtypes.ShardIteratorTypeAtSequenceNumber
+		input.SequenceNumber = aws.String(at)
+	case latest:
+		input.ShardIteratorType = streamtypes.ShardIteratorTypeLatest
+	}
+	out, err := p.client.streams.GetShardIterator(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return out.ShardIterator, nil
+}
+
+// streamRecords converts the records read from a shard, whose attribute values are
+// DynamoDB Streams types, to StreamRecords.
+func streamRecords(shardID string, records []streamtypes.Record) ([]StreamRecord, error) {
+	converted := make([]StreamRecord, 0, len(records))
+	for _, record := range records {
+		r := StreamRecord{
+			EventID:   aws.ToString(record.EventID),
+			EventName: string(record.EventName),
+			ShardID:   shardID,
+		}
+		if change := record.Dynamodb; change != nil {
+			var err error
+			r.SequenceNumber = aws.ToString(change.SequenceNumber)
+			r.CreatedAt = aws.ToTime(change.ApproximateCreationDateTime)
+			if r.Keys, err = fromStreamImage(change.Keys); err != nil {
+				return nil, err
+			}
+			if r.OldImage, err = fromStreamImage(change.OldImage); err != nil {
+				return nil, err
+			}
+			if r.NewImage, err = fromStreamImage(change.NewImage); err != nil {
+				return nil, err
+			}
+		}
+		converted = append(converted, r)
+	}
+	return converted, nil
+}
+
+func fromStreamImage(image map[string]streamtypes.AttributeValue) (map[string]types.AttributeValue, error) {
+	if image == nil {
+		return nil, nil
+	}
+	return attributevalue.FromDynamoDBStreamsMap(image)
+}
@@ -0,0 +1,347 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodbstreams"
+	streamtypes "github.com/aws/aws-sdk-go-v2/service/dynamodbstreams/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// streamsMock serves fixed shards. Iterators are "shard/position" and closed shards
+// end their iterators after the last record.
+type streamsMock struct {
+	shards  []streamtypes.Shard
+	records map[string][]streamtypes.Record
+	closed  map[string]bool
+	opened  []string
+	// failures is the number of DescribeStream calls that fail before one succeeds
+	failures int
+}
+
+func (m *streamsMock) DescribeStream(ctx context.Context, input *dynamodbstreams.DescribeStreamInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.DescribeStreamOutput, error) {
+	if m.failures > 0 {
+		m.failures--
+		return nil, errors.New("service unavailable")
+	}
+	shards := make([]streamtypes.Shard, len(m.shards))
+	for i, shard := range m.shards {
+		shards[i] = shard
+		if m.closed[aws.ToString(shard.ShardId)] {
+			shards[i].SequenceNumberRange = &streamtypes.SequenceNumberRange{EndingSequenceNumber: aws.String("end")}
+		}
+	}
+	return &dynamodbstreams.DescribeStreamOutput{StreamDescription: &streamtypes.StreamDescription{Shards: shards}}, nil
+}
+
+func (m *streamsMock) GetShardIterator(ctx context.Context, input *dynamodbstreams.GetShardIteratorInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetShardIteratorOutput, error) {
+	shardID := aws.ToString(input.ShardId)
+	m.opened = append(m.opened, shardID+" "+string(input.ShardIteratorType)+" "+aws.ToString(input.SequenceNumber))
+	position := 0
+	for i, record := range m.records[shardID] {
+		if aws.ToString(record.Dynamodb.SequenceNumber) != aws.ToString(input.SequenceNumber) {
+			continue
+		}
+		position = i + 1
+		if input.ShardIteratorType == streamtypes.ShardIteratorTypeAtSequenceNumber {
+			position = i
+		}
+	}
+	return &dynamodbstreams.GetShardIteratorOutput{ShardIterator: aws.String(shardID + "/" + strconv.Itoa(position))}, nil
+}
+
+func (m *streamsMock) GetRecords(ctx context.Context, input *dynamodbstreams.GetRecordsInput, optFns ...func(*dynamodbstreams.Options)) (*dynamodbstreams.GetRecordsOutput, error) {
+	shardID, position, _ := strings.Cut(aws.ToString(input.ShardIterator), "/")
+	from, _ := strconv.Atoi(position)
+	records := m.records[shardID][from:]
+	if len(records) > int(*input.Limit) {
+		records = records[:*input.Limit]
+	}
+	out := &dynamodbstreams.GetRecordsOutput{Records: records}
+	if next := from + len(records); next < len(m.records[shardID]) || !m.closed[shardID] {
+		out.NextShardIterator = aws.String(shardID + "/" + strconv.Itoa(next))
+	}
+	return out, nil
+}
+
+// leaseTableMock is an in-memory lease table evaluating conditions and updates with
+// the local client expressions.
+func leaseTableMock(leases map[string]map[string]interface{}) *dynamoClientMock {
+	var mu sync.Mutex
+	return &dynamoClientMock{
+		funcDescribeTable: func(ctx context.Context, input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+			return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{LatestStreamArn: aws.String("arn:stream")}}, nil
+		},
+		funcScan: func(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			out := &dynamodb.ScanOutput{}
+			for _, l := range leases {
+				item, _ := attributevalue.MarshalMap(l)
+				out.Items = append(out.Items, item)
+			}
+			return out, nil
+		},
+		funcUpdateItem: func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			shardID := input.Key["shard_id"].(*types.AttributeValueMemberS).Value
+			current := leases[shardID]
+			ok, err := evalCondition(input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, current)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, &types.ConditionalCheckFailedException{}
+			}
+			updated, err := applyUpdate(input.UpdateExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, current)
+			if err != nil {
+				return nil, err
+			}
+			updated["shard_id"] = shardID
+			leases[shardID] = updated
+			item, err := attributevalue.MarshalMap(updated)
+			return &dynamodb.UpdateItemOutput{Attributes: item}, err
+		},
+	}
+}
+
+func streamRecord(sequence, event string, image map[string]streamtypes.AttributeValue) streamtypes.Record {
+	record := streamtypes.Record{
+		EventID:   aws.String("event-" + sequence),
+		EventName: streamtypes.OperationType(event),
+		Dynamodb: &streamtypes.StreamRecord{
+			SequenceNumber: aws.String(sequence),
+			Keys:           map[string]streamtypes.AttributeValue{"id": image["id"]},
+		},
+	}
+	if event == "REMOVE" {
+		record.Dynamodb.OldImage = image
+	} else {
+		record.Dynamodb.NewImage = image
+	}
+	return record
+}
+
+func newStreamsMock() *streamsMock {
+	image := func(name string) map[string]streamtypes.AttributeValue {
+		return map[string]streamtypes.AttributeValue{
+			"id":   &streamtypes.AttributeValueMemberS{Value: "1"},
+			"name": &streamtypes.AttributeValueMemberS{Value: name},
+		}
+	}
+	return &streamsMock{
+		// the child is listed first to check that its parent is read before it
+		shards: []streamtypes.Shard{
+			{ShardId: aws.String("shard-2"), ParentShardId: aws.String("shard-1")},
+			{ShardId: aws.String("shard-1"), ParentShardId: aws.String("trimmed")},
+		},
+		records: map[string][]streamtypes.Record{
+			"shard-1": {streamRecord("1", "INSERT", image("Ada")), streamRecord("2", "MODIFY", image("Grace"))},
+			"shard-2": {streamRecord("3", "REMOVE", image("Grace"))},
+		},
+		closed: map[string]bool{"shard-1": true},
+	}
+}
+
+func TestStreamProcessor(t *testing.T) {
+	leases := map[string]map[string]interface{}{}
+	streams := newStreamsMock()
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"people": {TableName: "people", PartitionKeyField: "id"}, "leases": LeaseTable("leases")},
+		client:       leaseTableMock(leases),
+		streams:      streams,
+	}
+
+	type changed struct {
+		ID   string `dynamo:"id"`
+		Name string `dynamo:"name"`
+	}
+	var events []string
+	failed := false
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	processor := client.NewStreamProcessor("people", "leases", func(ctx context.Context, records []StreamRecord) error {
+		if !failed {
+			failed = true
+			return errors.New("try again")
+		}
+		for _, record := range records {
+			var item changed
+			if record.EventName == "REMOVE" {
+				assert.ErrorIs(t, record.UnmarshalNew(&item), ErrNotFound)
+				assert.NoError(t, record.UnmarshalOld(&item))
+				cancel()
+			} else {
+				assert.NoError(t, record.UnmarshalNew(&item))
+			}
+			events = append(events, record.ShardID+" "+record.EventName+" "+item.Name)
+		}
+		return nil
+	}, WithWorkerID("worker-1"), WithStreamPollInterval(time.Millisecond), WithShardDiscoveryInterval(time.Millisecond), WithStreamBatchSize(1))
+
+	assert.ErrorIs(t, processor.Run(ctx), context.Canceled)
+	assert.Equal(t, []string{"shard-1 INSERT Ada", "shard-1 MODIFY Grace", "shard-2 REMOVE Grace"}, events)
+	assert.Equal(t, []string{"shard-1 TRIM_HORIZON ", "shard-1 TRIM_HORIZON ", "shard-2 TRIM_HORIZON "}, streams.opened)
+	assert.Equal(t, map[string]interface{}{
+		"shard_id": "shard-1", "owner": "worker-1", "lease_expires": leases["shard-1"]["lease_expires"], "checkpoint": "2", "finished": true,
+	}, leases["shard-1"])
+	assert.Equal(t, map[string]interface{}{
+		"shard_id": "shard-2", "owner": "worker-1", "lease_expires": float64(0), "checkpoint": "3", "finished": false,
+	}, leases["shard-2"])
+}
+
+func TestStreamProcessorSharesShards(t *testing.T) {
+	leases := map[string]map[string]interface{}{
+		"shard-1": {"shard_id": "shard-1", "owner": "worker-1", "checkpoint": "1", "lease_expires": float64(0), "finished": false},
+		"shard-2": {"shard_id": "shard-2", "owner": "worker-2", "lease_expires": float64(time.Now().Add(time.Hour).UnixMilli())},
+	}
+	streams := newStreamsMock()
+	streams.closed["shard-2"] = true
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"people": {TableName: "people", PartitionKeyField: "id"}, "leases": LeaseTable("leases")},
+		client:       leaseTableMock(leases),
+		streams:      streams,
+	}
+
+	var sequences []string
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	processor := client.NewStreamProcessor("people", "leases", func(ctx context.Context, records []StreamRecord) error {
+		for _, record := range records {
+			sequences = append(sequences, record.SequenceNumber)
+		}
+		return nil
+	}, WithWorkerID("worker-3"), WithStreamPollInterval(time.Millisecond), WithShardDiscoveryInterval(time.Millisecond))
+
+	assert.ErrorIs(t, processor.Run(ctx), context.DeadlineExceeded)
+	// shard-1 resumes after its checkpoint, shard-2 is leased by a live worker
+	assert.Equal(t, []string{"2"}, sequences)
+	assert.Equal(t, []string{"shard-1 AFTER_SEQUENCE_NUMBER 1"}, streams.opened)
+	assert.Equal(t, "worker-2", leases["shard-2"]["owner"])
+	assert.Equal(t, true, leases["shard-1"]["finished"])
+}
+
+func TestStreamProcessorRetriesLatestBatch(t *testing.T) {
+	leases := map[string]map[string]interface{}{}
+	streams := newStreamsMock()
+	streams.shards = streams.shards[1:]
+	streams.closed = nil
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"people": {TableName: "people", PartitionKeyField: "id"}, "leases": LeaseTable("leases")},
+		client:       leaseTableMock(leases),
+		streams:      streams,
+	}
+
+	var sequences []string
+	failed := false
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	processor := client.NewStreamProcessor("people", "leases", func(ctx context.Context, records []StreamRecord) error {
+		if !failed {
+			failed = true
+			return errors.New("try again")
+		}
+		for _, record := range records {
+			sequences = append(sequences, record.SequenceNumber)
+		}
+		cancel()
+		return nil
+	}, WithWorkerID("worker-1"), WithStartAtLatest(), WithStreamPollInterval(time.Millisecond), WithShardDiscoveryInterval(time.Millisecond))
+
+	assert.ErrorIs(t, processor.Run(ctx), context.Canceled)
+	// the failed batch is read again from its first record, not from a new latest one
+	assert.Equal(t, []string{"1", "2"}, sequences)
+	assert.Equal(t, []string{"shard-1 LATEST ", "shard-1 AT_SEQUENCE_NUMBER 1"}, streams.opened)
+}
+
+func TestStreamProcessorStartAtLatest(t *testing.T) {
+	tests := map[string]struct {
+		leases  map[string]map[string]interface{}
+		opened  []string
+		skipped bool
+	}{
+		"skips closed shards": {
+			leases:  map[string]map[string]interface{}{},
+			opened:  []string{"shard-2 LATEST "},
+			skipped: true,
+		},
+		"reads the children of skipped shards from their latest record": {
+			leases:  map[string]map[string]interface{}{"shard-1": {"shard_id": "shard-1", "finished": true, "skipped": true}},
+			opened:  []string{"shard-2 LATEST "},
+			skipped: true,
+		},
+		"reads the children of read shards from their oldest record": {
+			leases: map[string]map[string]interface{}{"shard-1": {"shard_id": "shard-1", "finished": true, "checkpoint": "2"}},
+			opened: []string{"shard-2 TRIM_HORIZON "},
+		},
+		"resumes shards from their checkpoint": {
+			leases: map[string]map[string]interface{}{"shard-1": {"shard_id": "shard-1", "checkpoint": "1"}},
+			opened: []string{"shard-1 AFTER_SEQUENCE_NUMBER 1", "shard-2 TRIM_HORIZON "},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			streams := newStreamsMock()
+			client := &Implementation{
+				DynamoTables: map[string]DynamoTable{"people": {TableName: "people", PartitionKeyField: "id"}, "leases": LeaseTable("leases")},
+				client:       leaseTableMock(tt.leases),
+				streams:      streams,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			processor := client.NewStreamProcessor("people", "leases", func(ctx context.Context, records []StreamRecord) error {
+				if records[0].ShardID == "shard-2" {
+					cancel()
+				}
+				return nil
+			}, WithWorkerID("worker-1"), WithStartAtLatest(), WithStreamPollInterval(time.Millisecond), WithShardDiscoveryInterval(time.Millisecond))
+
+			assert.ErrorIs(t, processor.Run(ctx), context.Canceled)
+			assert.Equal(t, tt.opened, streams.opened)
+			assert.Equal(t, true, tt.leases["shard-1"]["finished"])
+			assert.Equal(t, tt.skipped, tt.leases["shard-1"]["skipped"] == true)
+		})
+	}
+}
+
+func TestStreamProcessorRetriesDiscovery(t *testing.T) {
+	leases := map[string]map[string]interface{}{}
+	streams := newStreamsMock()
+	streams.failures = 2
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"people": {TableName: "people", PartitionKeyField: "id"}, "leases": LeaseTable("leases")},
+		client:       leaseTableMock(leases),
+		streams:      streams,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	processor := client.NewStreamProcessor("people", "leases", func(ctx context.Context, records []StreamRecord) error {
+		cancel()
+		return nil
+	}, WithWorkerID("worker-1"), WithStreamPollInterval(time.Millisecond), WithShardDiscoveryInterval(time.Millisecond))
+
+	assert.ErrorIs(t, processor.Run(ctx), context.Canceled)
+	assert.Zero(t, streams.failures)
+	assert.Equal(t, "shard-1", leases["shard-1"]["shard_id"])
+
+	noStream := leaseTableMock(leases)
+	noStream.funcDescribeTable = func(ctx context.Context, input *dynamodb.DescribeTableInput) (*dynamodb.DescribeTableOutput, error) {
+		return &dynamodb.DescribeTableOutput{Table: &types.TableDescription{}}, nil
+	}
+	client.client = noStream
+	err := processor.Run(context.Background())
+	assert.EqualError(t, err, "dynamo: table has no stream: people")
+}
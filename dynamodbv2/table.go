@@ -0,0 +1,151 @@
+package dynamodb
+
+import (
+	"context"
+	"iter"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+)
+
+// Table is a typed repository over one table of an Implementation. Reads return T
+// and writes accept T, so type mismatches are caught at compile time instead of
+// when unmarshalling into an interface{} bind target. Items are marshalled with
+// the same Tagkey options used by the rest of the client.
+type Table[T any] struct {
+	client *Implementation
+	name   string
+}
+
+// NewTable registers the table definition on the client and returns a typed
+// repository for it, or the error of RegisterTable when the definition is invalid.
+//
+//	users, err := dynamodb.NewTable[User](client, dynamodb.DynamoTable{
+//		TableName:         "users",
+//		PartitionKeyField: "id",
+//	})
+//	err = users.Save(ctx, &User{ID: "123", Name: "Ada"})
+//	user, err := users.Get(ctx, "123")
+func NewTable[T any](client *Implementation, table DynamoTable) (*Table[T], error) {
+	if err := client.RegisterTable(table); err != nil {
+		return nil, err
+	}
+	return &Table[T]{
+		client: client,
+		name:   table.TableName,
+	}, nil
+}
+
+// Name returns the name of the underlying table.
+func (t *Table[T]) Name() string {
+	return t.name
+}
+
+// Save puts the item in the table. The item is passed by pointer so that it receives
+// its new version on versioned tables.
+func (t *Table[T]) Save(ctx context.Context, item *T, opts ...WriteOption) error {
+	return t.client.SaveWithContext(ctx, t.name, item, opts...)
+}
+
+// Get returns the item with the given partition key, or ErrNotFound.
+func (t *Table[T]) Get(ctx context.Context, partitionKey interface{}) (T, error) {
+	var item T
+	err := t.client.GetByKey(ctx, t.name, Key{PartitionKey: partitionKey}, &item)
+	return item, err
+}
+
+// GetWithSort returns the item with the given partition and sort key, or ErrNotFound.
+func (t *Table[T]) GetWithSort(ctx context.Context, partitionKey interface{}, sortKey interface{}) (T, error) {
+	var item T
+	err := t.client.GetByKey(ctx, t.name, Key{PartitionKey: partitionKey, SortKey: sortKey}, &item)
+	return item, err
+}
+
+// Query returns the items matching the query expression and what the query read.
+// pageSize and pageNumber behave as in Implementation.QueryExpression.
+func (t *Table[T]) Query(ctx context.Context, query expression.Expression, pageSize int32, pageNumber int32) ([]T, *QueryResult, error) {
+	var items []T
+	result, err := t.client.QueryExpressionResultWithContext(ctx, t.name, query, pageSize, pageNumber, &items)
+	return items, result, err
+}
+
+// QueryIndex returns the items matching the query expression on the given index.
+func (t *Table[T]) QueryIndex(ctx context.Context, index string, query expression.Expression, pageSize int32, pageNumber int32) ([]T, *QueryResult, error) {
+	var items []T
+	result, err := t.client.QueryGSIResultWithContext(ctx, t.name, index, query, pageSize, pageNumber, &items)
+	return items, result, err
+}
+
+// QueryPage returns one page of the items matching the query expression, with the
+// cursor of the next page in the result, as in Implementation.QueryExpressionPage.
+func (t *Table[T]) QueryPage(ctx context.Context, query expression.Expression, pageSize int32, cursor string) ([]T, *QueryResult, error) {
+	var items []T
+	result, err := t.client.QueryExpressionPageWithContext(ctx, t.name, query, pageSize, cursor, &items)
+	return items, result, err
+}
+
+// QueryKey returns the items of a partition matching the KeyQuery in sort key order.
+func (t *Table[T]) QueryKey(ctx context.Context, query KeyQuery) ([]T, *QueryResult, error) {
+	var items []T
+	result, err := t.client.QueryKeyWithContext(ctx, t.name, query, &items)
+	return items, result, err
+}
+
+// QueryAll iterates over every item matching the query expression. Pages are
+// requested as the items are consumed, up to the table MaxPageSize items each, and
+// breaking out of the loop stops further requests.
+//
+//	for order, err := range orders.QueryAll(ctx, query) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (t *Table[T]) QueryAll(ctx context.Context, query expression.Expression) iter.Seq2[T, error] {
+	return Decode[T](t.client.QueryItems(ctx, t.name, query))
+}
+
+// QueryIndexAll is QueryAll on the given index.
+func (t *Table[T]) QueryIndexAll(ctx context.Context, index string, query expression.Expression) iter.Seq2[T, error] {
+	return Decode[T](t.client.QueryGSIItems(ctx, t.name, index, query))
+}
+
+// ScanAll iterates over every item of the table matching the filter of the
+// expression, with a sequential scan that stops when the loop ends.
+func (t *Table[T]) ScanAll(ctx context.Context, filter expression.Expression) iter.Seq2[T, error] {
+	return Decode[T](t.client.ScanItems(ctx, t.name, filter))
+}
+
+// Scan reads the whole table and returns the items matching the filter and
+// projection of the expression. An empty expression returns every item.
+func (t *Table[T]) Scan(ctx context.Context, filter expression.Expression, opts ...ScanOption) ([]T, *QueryResult, error) {
+	var items []T
+	result, err := t.client.Scan(ctx, t.name, filter, &items, opts...)
+	return items, result, err
+}
+
+// ScanEach reads the table and calls fn with every item matching the filter, as in
+// Implementation.ScanPages. fn is never called concurrently.
+func (t *Table[T]) ScanEach(ctx context.Context, filter expression.Expression, fn func(item T) error, opts ...ScanOption) (*QueryResult, error) {
+	return t.client.ScanPages(ctx, t.name, filter, func(page ScanPage) error {
+		var items []T
+		if err := page.Unmarshal(&items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, opts...)
+}
+
+// BatchSave puts every item with BatchWriteItem, as in Implementation.BatchSave.
+func (t *Table[T]) BatchSave(ctx context.Context, items []T, opts ...BatchOption) error {
+	return t.client.BatchSave(ctx, t.name, items, opts...)
+}
+
+// BatchDelete deletes the items with the given keys, as in Implementation.BatchDelete.
+func (t *Table[T]) BatchDelete(ctx context.Context, keys []Key, opts ...BatchOption) error {
+	return t.client.BatchDelete(ctx, t.name, keys, opts...)
+}
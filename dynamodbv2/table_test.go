@@ -0,0 +1,180 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type order struct {
+	ID     string `dynamo:"order_id"`
+	Status string `dynamo:"status"`
+	Total  int    `dynamo:"total"`
+}
+
+func orderItem(id, status string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"order_id": &types.AttributeValueMemberS{Value: id},
+		"status":   &types.AttributeValueMemberS{Value: status},
+		"total":    &types.AttributeValueMemberN{Value: "10"},
+	}
+}
+
+func TestTableGetAndSave(t *testing.T) {
+	var saved map[string]types.AttributeValue
+	client := &Implementation{client: &dynamoClientMock{
+		funcPutItem: func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			saved = input.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		funcGetItem: func(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			assert.Equal(t, "orders", *input.TableName)
+			assert.Equal(t, &types.AttributeValueMemberS{Value: "1"}, input.Key["order_id"])
+			return &dynamodb.GetItemOutput{Item: orderItem("1", "paid")}, nil
+		},
+	}}
+	orders, err := NewTable[order](client, DynamoTable{TableName: "orders", PartitionKeyField: "order_id"})
+	assert.NoError(t, err)
+
+	err = orders.Save(context.Background(), &order{ID: "1", Status: "new", Total: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, orderItem("1", "new"), saved)
+
+	o, err := orders.Get(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, order{ID: "1", Status: "paid", Total: 10}, o)
+}
+
+func TestNewTableRejectsInvalidDefinitions(t *testing.T) {
+	client := &Implementation{}
+
+	_, err := NewTable[order](client, DynamoTable{TableName: "orders"})
+	assert.Error(t, err)
+
+	_, err = NewTable[order](client, DynamoTable{TableName: "orders", Model: "order"})
+	assert.ErrorIs(t, err, ErrInvalidModel)
+	_, err = client.table("orders")
+	assert.ErrorIs(t, err, ErrUnknownTable)
+}
+
+func TestTableGetNotFound(t *testing.T) {
+	client := &Implementation{client: &dynamoClientMock{
+		funcGetItem: func(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{}, nil
+		},
+	}}
+	orders, err := NewTable[order](client, DynamoTable{TableName: "orders", PartitionKeyField: "order_id"})
+	assert.NoError(t, err)
+
+	_, err = orders.Get(context.Background(), "1")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestTableQueryAndScan(t *testing.T) {
+	scans := 0
+	client := &Implementation{client: &dynamoClientMock{
+		funcQuery: func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			assert.Equal(t, "status-index", *input.IndexName)
+			return &dynamodb.QueryOutput{
+				Items:            []map[string]types.AttributeValue{orderItem("1", "paid"), orderItem("2", "paid")},
+				Count:            2,
+				ConsumedCapacity: &types.ConsumedCapacity{CapacityUnits: aws.Float64(1)},
+			}, nil
+		},
+		funcScan: func(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+			scans++
+			if input.ExclusiveStartKey == nil {
+				return &dynamodb.ScanOutput{
+					Items:            []map[string]types.AttributeValue{orderItem("1", "paid")},
+					LastEvaluatedKey: map[string]types.AttributeValue{"order_id": &types.AttributeValueMemberS{Value: "1"}},
+				}, nil
+			}
+			return &dynamodb.ScanOutput{Items: []map[string]types.AttributeValue{orderItem("2", "new")}}, nil
+		},
+	}}
+	orders, err := NewTable[order](client, DynamoTable{TableName: "orders", PartitionKeyField: "order_id",
+		GlobalIndexes: []Index{{Name: "status-index", PartitionKeyField: "status"}}})
+	assert.NoError(t, err)
+
+	query, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key("status").Equal(expression.Value("paid"))).
+		Build()
+	assert.NoError(t, err)
+
+	paid, result, err := orders.QueryIndex(context.Background(), "status-index", query, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), result.Count)
+	assert.Len(t, paid, 2)
+	assert.Equal(t, "2", paid[1].ID)
+
+	all, _, err := orders.Scan(context.Background(), expression.Expression{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, scans)
+	assert.Equal(t, []order{{ID: "1", Status: "paid", Total: 10}, {ID: "2", Status: "new", Total: 10}}, all)
+}
+
+func TestTableIterators(t *testing.T) {
+	var queries []*dynamodb.QueryInput
+	scans := 0
+	client := &Implementation{client: &dynamoClientMock{
+		funcQuery: func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			queries = append(queries, input)
+			page := strconv.Itoa(len(queries))
+			return &dynamodb.QueryOutput{
+				Items:            []map[string]types.AttributeValue{orderItem(page+"-1", "paid"), orderItem(page+"-2", "paid")},
+				LastEvaluatedKey: map[string]types.AttributeValue{"order_id": &types.AttributeValueMemberS{Value: page + "-2"}},
+			}, nil
+		},
+		funcScan: func(ctx context.Context, input *dynamodb.ScanInput) (*dynamodb.ScanOutput, error) {
+			scans++
+			if scans == 1 {
+				return &dynamodb.ScanOutput{
+					Items:            []map[string]types.AttributeValue{orderItem("1", "paid")},
+					LastEvaluatedKey: map[string]types.AttributeValue{"order_id": &types.AttributeValueMemberS{Value: "1"}},
+				}, nil
+			}
+			return nil, errors.New("throttled")
+		},
+	}}
+	orders, err := NewTable[order](client, DynamoTable{TableName: "orders", PartitionKeyField: "order_id", MaxPageSize: 2,
+		GlobalIndexes: []Index{{Name: "status-index", PartitionKeyField: "status"}}})
+	assert.NoError(t, err)
+
+	query, err := expression.NewBuilder().
+		WithKeyCondition(expression.Key("order_id").Equal(expression.Value("1"))).
+		Build()
+	assert.NoError(t, err)
+
+	var ids []string
+	for o, err := range orders.QueryIndexAll(context.Background(), "status-index", query) {
+		assert.NoError(t, err)
+		ids = append(ids, o.ID)
+		if len(ids) == 3 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"1-1", "1-2", "2-1"}, ids)
+	assert.Len(t, queries, 2)
+	assert.Equal(t, int32(2), *queries[0].Limit)
+	assert.Equal(t, "status-index", *queries[1].IndexName)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "1-2"}, queries[1].ExclusiveStartKey["order_id"])
+
+	var scanned []order
+	var scanErr error
+	for o, err := range orders.ScanAll(context.Background(), expression.Expression{}) {
+		if err != nil {
+			scanErr = err
+			break
+		}
+		scanned = append(scanned, o)
+	}
+	assert.Len(t, scanned, 1)
+	assert.EqualError(t, scanErr, "failed to scan items: throttled")
+}
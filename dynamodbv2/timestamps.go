@@ -0,0 +1,83 @@
+package dynamodb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// WithClock sets the clock used for timestamps and TTL expiry, so tests can control
+// time. Defaults to time.Now.
+func WithClock(clock func() time.Time) funcTable {
+	return func(i *Implementation) {
+		i.clock = clock
+	}
+}
+
+func (i *Implementation) now() time.Time {
+	if i.clock != nil {
+		return i.clock()
+	}
+	return time.Now()
+}
+
+// timestamp formats a write time as stored in timestamp attributes, which decodes into
+// time.Time fields.
+func timestamp(now time.Time) string {
+	return now.UTC().Format(time.RFC3339Nano)
+}
+
+// stampTimestamps sets the updated timestamp of an item being saved, and its created
+// timestamp when the item has none.
+func (d DynamoTable) stampTimestamps(item map[string]types.AttributeValue, now time.Time) {
+	if d.CreatedAtField != "" && isZeroTimestamp(item[d.CreatedAtField]) {
+		item[d.CreatedAtField] = &types.AttributeValueMemberS{Value: timestamp(now)}
+	}
+	if d.UpdatedAtField != "" {
+		item[d.UpdatedAtField] = &types.AttributeValueMemberS{Value: timestamp(now)}
+	}
+}
+
+const (
+	createdAtName  = "#created_at"
+	updatedAtName  = "#updated_at"
+	timestampValue = ":timestamp"
+)
+
+// withTimestamps adds the timestamp actions to an update expression. The created
+// timestamp is only set when the stored item has none.
+func (d DynamoTable) withTimestamps(update *string, names map[string]string, values map[string]types.AttributeValue, now time.Time) (*string, map[string]string, map[string]types.AttributeValue) {
+	var actions []string
+	addNames := map[string]string{}
+	if d.CreatedAtField != "" {
+		addNames[createdAtName] = d.CreatedAtField
+		actions = append(actions, fmt.Sprintf("%s = if_not_exists(%s, %s)", createdAtName, createdAtName, timestampValue))
+	}
+	if d.UpdatedAtField != "" {
+		addNames[updatedAtName] = d.UpdatedAtField
+		actions = append(actions, updatedAtName+" = "+timestampValue)
+	}
+	if len(actions) == 0 {
+		return update, names, values
+	}
+	names, values = withPlaceholders(names, values, addNames,
+		map[string]types.AttributeValue{timestampValue: &types.AttributeValueMemberS{Value: timestamp(now)}})
+	return addUpdateAction(update, "SET", strings.Join(actions, ", ")), names, values
+}
+
+// isZeroTimestamp reports whether a timestamp attribute is missing or holds the zero
+// value of its Go field, such as an unset time.Time.
+func isZeroTimestamp(av types.AttributeValue) bool {
+	switch v := av.(type) {
+	case nil, *types.AttributeValueMemberNULL:
+		return true
+	case *types.AttributeValueMemberS:
+		t, err := time.Parse(time.RFC3339Nano, v.Value)
+		return v.Value == "" || (err == nil && t.IsZero())
+	case *types.AttributeValueMemberN:
+		return v.Value == "0"
+	}
+	return false
+}
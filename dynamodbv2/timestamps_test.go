@@ -0,0 +1,164 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type note struct {
+	ID        string    `dynamo:"id" json:"id"`
+	Text      string    `dynamo:"text" json:"text"`
+	CreatedAt time.Time `dynamo:"created_at" json:"created_at"`
+	UpdatedAt time.Time `dynamo:"updated_at" json:"updated_at"`
+}
+
+func notesTable() DynamoTable {
+	return DynamoTable{TableName: "notes", PartitionKeyField: "id", CreatedAtField: "created_at", UpdatedAtField: "updated_at"}
+}
+
+func TestSaveAndUpdateStampTimestamps(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var puts []map[string]types.AttributeValue
+	var update *dynamodb.UpdateItemInput
+	client := NewImplementation(aws.Config{}, WithTable(notesTable()), WithClock(fixedClock(&now)))
+	client.client = &dynamoClientMock{
+		funcPutItem: func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			puts = append(puts, input.Item)
+			return &dynamodb.PutItemOutput{}, nil
+		},
+		funcUpdateItem: func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			update = input
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+	}
+
+	assert.NoError(t, client.Save("notes", note{ID: "1"}))
+	created := time.Date(2023, 5, 6, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, client.Save("notes", note{ID: "2", CreatedAt: created}))
+
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-01-02T03:04:05Z"}, puts[0]["created_at"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-01-02T03:04:05Z"}, puts[0]["updated_at"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2023-05-06T00:00:00Z"}, puts[1]["created_at"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-01-02T03:04:05Z"}, puts[1]["updated_at"])
+
+	err := client.Update("notes", "1", expression.Set(expression.Name("text"), expression.Value("hi")), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "SET #0 = :0, #created_at = if_not_exists(#created_at, :timestamp), #updated_at = :timestamp\n", *update.UpdateExpression)
+	assert.Equal(t, "created_at", update.ExpressionAttributeNames["#created_at"])
+	assert.Equal(t, "updated_at", update.ExpressionAttributeNames["#updated_at"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-01-02T03:04:05Z"}, update.ExpressionAttributeValues[":timestamp"])
+}
+
+func TestUpdateTimestampsKeepBuilder(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var updates []*dynamodb.UpdateItemInput
+	table := notesTable()
+	table.VersionField = "version"
+	client := NewImplementation(aws.Config{}, WithTable(table), WithClock(fixedClock(&now)))
+	client.client = &dynamoClientMock{
+		funcUpdateItem: func(ctx context.Context, input *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+			updates = append(updates, input)
+			return &dynamodb.UpdateItemOutput{}, nil
+		},
+		funcTransactWriteItems: func(ctx context.Context, input *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+			item := input.TransactItems[0].Update
+			updates = append(updates, &dynamodb.UpdateItemInput{
+				UpdateExpression:          item.UpdateExpression,
+				ExpressionAttributeNames:  item.ExpressionAttributeNames,
+				ExpressionAttributeValues: item.ExpressionAttributeValues,
+			})
+			return &dynamodb.TransactWriteItemsOutput{}, nil
+		},
+	}
+
+	// the same builder is reused for every write
+	update := expression.Set(expression.Name("text"), expression.Value("hi"))
+	assert.NoError(t, client.Update("notes", "1", update, nil))
+	now = now.Add(time.Hour)
+	assert.NoError(t, client.Update("notes", "2", update, nil))
+	assert.NoError(t, client.WriteTransaction().Update("notes", "3", update).Commit(context.Background()))
+
+	assert.Len(t, updates, 3)
+	for _, input := range updates {
+		assert.Equal(t, "ADD #version :version_increment\nSET #0 = :0, #created_at = if_not_exists(#created_at, :timestamp), #updated_at = :timestamp\n", *input.UpdateExpression)
+		assert.Len(t, input.ExpressionAttributeValues, 3)
+	}
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-01-02T04:04:05Z"}, updates[1].ExpressionAttributeValues[":timestamp"])
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SET #0 = :0\n", *expr.Update())
+}
+
+func TestSaveKeepsStoredCreatedTimestamp(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	stored := map[string]map[string]types.AttributeValue{}
+	puts := 0
+	client := NewImplementation(aws.Config{}, WithTable(notesTable()), WithClock(fixedClock(&now)))
+	client.client = &dynamoClientMock{
+		funcPutItem: func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			puts++
+			id := input.Item["id"].(*types.AttributeValueMemberS).Value
+			var current map[string]interface{}
+			assert.NoError(t, attributevalue.UnmarshalMap(stored[id], &current))
+			ok, err := evalCondition(input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, current)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				exception := &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+				if input.ReturnValuesOnConditionCheckFailure == types.ReturnValuesOnConditionCheckFailureAllOld {
+					exception.Item = stored[id]
+				}
+				return nil, exception
+			}
+			stored[id] = input.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}
+
+	assert.NoError(t, client.Save("notes", note{ID: "1", Text: "a"}))
+	assert.Equal(t, 1, puts)
+	now = now.Add(time.Hour)
+	assert.NoError(t, client.Save("notes", note{ID: "1", Text: "b"}))
+	assert.Equal(t, 3, puts)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-01-02T03:04:05Z"}, stored["1"]["created_at"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "2024-01-02T04:04:05Z"}, stored["1"]["updated_at"])
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "b"}, stored["1"]["text"])
+
+	// a failing condition of the caller is still reported
+	err := client.Save("notes", note{ID: "1", Text: "c"}, IfNotExists())
+	assert.ErrorIs(t, err, ErrConditionFailed)
+	assert.Equal(t, &types.AttributeValueMemberS{Value: "b"}, stored["1"]["text"])
+}
+
+func TestDynamoLocalDevelopmentTimestamps(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	client := NewLocalClient().WithTable(notesTable()).WithClock(fixedClock(&now))
+
+	assert.NoError(t, client.Save("notes", note{ID: "1", Text: "a"}))
+	now = now.Add(time.Hour)
+	var n note
+	err := client.Update("notes", "1", expression.Set(expression.Name("text"), expression.Value("b")), &n)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", n.Text)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), n.CreatedAt)
+	assert.Equal(t, time.Date(2024, 1, 2, 4, 4, 5, 0, time.UTC), n.UpdatedAt)
+
+	err = client.Update("notes", "2", expression.Set(expression.Name("text"), expression.Value("c")), &n)
+	assert.NoError(t, err)
+	assert.Equal(t, n.UpdatedAt, n.CreatedAt)
+
+	now = now.Add(time.Hour)
+	assert.NoError(t, client.Save("notes", note{ID: "1", Text: "d"}))
+	assert.NoError(t, client.GetOne("notes", "1", &n))
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), n.CreatedAt)
+	assert.Equal(t, time.Date(2024, 1, 2, 5, 4, 5, 0, time.UTC), n.UpdatedAt)
+}
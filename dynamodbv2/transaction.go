@@ -0,0 +1,521 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// maxTransactionItems is the maximum number of items DynamoDB accepts in one transaction.
+const maxTransactionItems = 100
+
+// Cancellation reason codes returned by DynamoDB for each item of a canceled transaction.
+const (
+	ReasonNone                   = "None"
+	ReasonConditionalCheckFailed = "ConditionalCheckFailed"
+	ReasonTransactionConflict    = "TransactionConflict"
+)
+
+// WriteTransaction collects puts, updates, deletes and condition checks on the tables
+// registered in the client and applies them all or none with TransactWriteItems.
+//
+//	err := client.WriteTransaction().
+//		Save("orders", order, dynamodb.IfNotExists()).
+//		Update("users", order.UserID, expression.Add(expression.Name("orders"), expression.Value(1))).
+//		Commit(ctx)
+type WriteTransaction struct {
+	client  *Implementation
+	items   []types.TransactWriteItem
+	entries []transactionEntry
+	err     error
+}
+
+// transactionEntry keeps what is needed to decode the cancellation reason of one item
+// of a transaction and to complete it once the transaction succeeds.
+type transactionEntry struct {
+	table   string
+	guarded bool
+	done    func() error
+}
+
+// TransactionError is returned when DynamoDB cancels a transaction. Reasons holds one
+// entry per item, in the order the items were added to the transaction. It matches
+// ErrConditionFailed and ErrVersionConflict with errors.Is when an item failed for
+// those causes.
+type TransactionError struct {
+	Reasons []TransactionReason
+	err     error
+}
+
+// TransactionReason is the decoded cancellation reason of one transaction item.
+type TransactionReason struct {
+	// Table is the name of the table the item belongs to.
+	Table string
+	// Code is the DynamoDB reason code, ReasonNone for items that did not fail.
+	Code    string
+	Message string
+	// Item holds the stored item when a condition check failed.
+	Item    map[string]types.AttributeValue
+	guarded bool
+}
+
+// WriteTransaction starts a new write transaction on the client tables.
+func (i *Implementation) WriteTransaction() *WriteTransaction {
+	return &WriteTransaction{client: i}
+}
+
+// Save adds a put of the item. Conditions, IfNotExists and version guards work as in
+// Implementation.Save.
+func (t *WriteTransaction) Save(table string, values interface{}, opts ...WriteOption) *WriteTransaction {
+	def, ops, ok := t.prepare(table, opts)
+	if !ok {
+		return t
+	}
+	item, err := attributevalue.MarshalMapWithOptions(values, func(h *attributevalue.EncoderOptions) {
+		h.TagKey = Tagkey
+	})
+	if err != nil {
+		t.err = fmt.Errorf("failed to DynamoDB marshal Record, %w", err)
+		return t
+	}
+	now := t.client.now()
+	def.stampExpiry(item, ops, now)
+	def.stampTimestamps(item, now)
+
+	var done func() error
+	if def.VersionField != "" {
+		if err := versionTarget(def, values); err != nil {
+			t.err = err
+			return t
+		}
+		version, err := itemVersion(item, def.VersionField)
+		if err != nil {
+			t.err = err
+			return t
+		}
+		item[def.VersionField] = &types.AttributeValueMemberN{Value: strconv.FormatInt(version+1, 10)}
+		ops.version = &version
+		done = func() error {
+			return setVersion(values, def.VersionField, version+1)
+		}
+	}
+
+	put := &types.Put{
+		TableName: aws.String(def.TableName),
+		Item:      item,
+	}
+	if condition := ops.conditionBuilder(def); condition != nil {
+		cond, err := expression.NewBuilder().WithCondition(*condition).Build()
+		if err != nil {
+			t.err = err
+			return t
+		}
+		put.ConditionExpression = cond.Condition()
+		put.ExpressionAttributeNames = cond.Names()
+		put.ExpressionAttributeValues = cond.Values()
+		put.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+	t.add(types.TransactWriteItem{Put: put}, transactionEntry{table: table, guarded: ops.guarded(def), done: done})
+	return t
+}
+
+// Update adds an update of the item with the given partition key.
+func (t *WriteTransaction) Update(table string, partitionKey interface{}, update expression.UpdateBuilder, opts ...WriteOption) *WriteTransaction {
+	def, ops, ok := t.prepare(table, opts)
+	if !ok {
+		return t
+	}
+	key, ok := t.key(def, partitionKey)
+	if !ok {
+		return t
+	}
+	return t.update(def, ops, key, update)
+}
+
+// UpdateWithSort adds an update of the item with the given partition and sort key.
+func (t *WriteTransaction) UpdateWithSort(table string, partitionKey interface{}, sortKey interface{}, update expression.UpdateBuilder, opts ...WriteOption) *WriteTransaction {
+	def, ops, ok := t.prepare(table, opts)
+	if !ok {
+		return t
+	}
+	key, ok := t.key(def, partitionKey, sortKey)
+	if !ok {
+		return t
+	}
+	return t.update(def, ops, key, update)
+}
+
+func (t *WriteTransaction) update(def DynamoTable, ops *writeOptions, key map[string]types.AttributeValue, update expression.UpdateBuilder) *WriteTransaction {
+	expr, err := ops.builder(def).WithUpdate(update).Build()
+	if err != nil {
+		t.err = err
+		return t
+	}
+	item := &types.Update{
+		TableName:           aws.String(def.TableName),
+		Key:                 key,
+		ConditionExpression: expr.Condition(),
+	}
+	item.UpdateExpression, item.ExpressionAttributeNames, item.ExpressionAttributeValues = def.updateActions(expr, t.client.now())
+	if item.ConditionExpression != nil {
+		item.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+	t.add(types.TransactWriteItem{Update: item}, transactionEntry{table: def.TableName, guarded: ops.guarded(def)})
+	return t
+}
+
+// Delete adds a delete of the item with the given partition key.
+func (t *WriteTransaction) Delete(table string, partitionKey interface{}, opts ...WriteOption) *WriteTransaction {
+	def, ops, ok := t.prepare(table, opts)
+	if !ok {
+		return t
+	}
+	key, ok := t.key(def, partitionKey)
+	if !ok {
+		return t
+	}
+	return t.delete(def, ops, key)
+}
+
+// DeleteWithSort adds a delete of the item with the given partition and sort key.
+func (t *WriteTransaction) DeleteWithSort(table string, partitionKey interface{}, sortKey interface{}, opts ...WriteOption) *WriteTransaction {
+	def, ops, ok := t.prepare(table, opts)
+	if !ok {
+		return t
+	}
+	key, ok := t.key(def, partitionKey, sortKey)
+	if !ok {
+		return t
+	}
+	return t.delete(def, ops, key)
+}
+
+func (t *WriteTransaction) delete(def DynamoTable, ops *writeOptions, key map[string]types.AttributeValue) *WriteTransaction {
+	item := &types.Delete{
+		TableName: aws.String(def.TableName),
+		Key:       key,
+	}
+	if condition := ops.conditionBuilder(def); condition != nil {
+		cond, err := expression.NewBuilder().WithCondition(*condition).Build()
+		if err != nil {
+			t.err = err
+			return t
+		}
+		item.ConditionExpression = cond.Condition()
+		item.ExpressionAttributeNames = cond.Names()
+		item.ExpressionAttributeValues = cond.Values()
+		item.ReturnValuesOnConditionCheckFailure = types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+	t.add(types.TransactWriteItem{Delete: item}, transactionEntry{table: def.TableName, guarded: ops.guarded(def)})
+	return t
+}
+
+// Check adds a condition on the item with the given partition key, which is not
+// modified. The transaction is canceled when the condition is not met.
+func (t *WriteTransaction) Check(table string, partitionKey interface{}, condition expression.ConditionBuilder) *WriteTransaction {
+	def, _, ok := t.prepare(table, nil)
+	if !ok {
+		return t
+	}
+	key, ok := t.key(def, partitionKey)
+	if !ok {
+		return t
+	}
+	return t.check(def, key, condition)
+}
+
+// CheckWithSort adds a condition on the item with the given partition and sort key.
+func (t *WriteTransaction) CheckWithSort(table string, partitionKey interface{}, sortKey interface{}, condition expression.ConditionBuilder) *WriteTransaction {
+	def, _, ok := t.prepare(table, nil)
+	if !ok {
+		return t
+	}
+	key, ok := t.key(def, partitionKey, sortKey)
+	if !ok {
+		return t
+	}
+	return t.check(def, key, condition)
+}
+
+func (t *WriteTransaction) check(def DynamoTable, key map[string]types.AttributeValue, condition expression.ConditionBuilder) *WriteTransaction {
+	cond, err := expression.NewBuilder().WithCondition(condition).Build()
+	if err != nil {
+		t.err = err
+		return t
+	}
+	t.add(types.TransactWriteItem{ConditionCheck: &types.ConditionCheck{
+		TableName:                           aws.String(def.TableName),
+		Key:                                 key,
+		ConditionExpression:                 cond.Condition(),
+		ExpressionAttributeNames:            cond.Names(),
+		ExpressionAttributeValues:           cond.Values(),
+		ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+	}}, transactionEntry{table: def.TableName})
+	return t
+}
+
+// Commit applies every write of the transaction atomically. When DynamoDB cancels the
+// transaction the returned error is a *TransactionError.
+func (t *WriteTransaction) Commit(ctx context.Context) error {
+	if t.err != nil {
+		return t.err
+	}
+	if len(t.items) == 0 {
+		return nil
+	}
+	if len(t.items) > maxTransactionItems {
+		return fmt.Errorf("dynamo: a transaction supports up to %d items, got %d", maxTransactionItems, len(t.items))
+	}
+
+	log.Printf("[DynamoDB] executing write transaction with %d items", len(t.items))
+	_, err := t.client.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: t.items,
+	})
+	if err != nil {
+		return newTransactionError(err, t.entries)
+	}
+	for _, entry := range t.entries {
+		if entry.done == nil {
+			continue
+		}
+		if err := entry.done(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepare resolves the table definition and options of a new item. It records an
+// error, reported by Commit, when the table was not registered.
+func (t *WriteTransaction) prepare(table string, opts []WriteOption) (DynamoTable, *writeOptions, bool) {
+	if t.err != nil {
+		return DynamoTable{}, nil, false
+	}
+	def, err := t.client.table(table)
+	if err != nil {
+		t.err = err
+		return DynamoTable{}, nil, false
+	}
+	ops := newWriteOptions(opts)
+	if ops.oldValue != nil {
+		t.err = errors.New("dynamo: WithOldValue is not supported in transactions")
+		return DynamoTable{}, nil, false
+	}
+	return def, ops, true
+}
+
+// key builds the key of an item, recording an error when it does not match the table.
+func (t *WriteTransaction) key(def DynamoTable, partitionKey interface{}, sortKey ...interface{}) (map[string]types.AttributeValue, bool) {
+	key, err := def.primaryKey(partitionKey, sortKey...)
+	if err != nil {
+		t.err = err
+		return nil, false
+	}
+	return key, true
+}
+
+func (t *WriteTransaction) add(item types.TransactWriteItem, entry transactionEntry) {
+	t.items = append(t.items, item)
+	t.entries = append(t.entries, entry)
+}
+
+// ReadTransaction reads items from the tables registered in the client as a single
+// consistent snapshot with TransactGetItems.
+type ReadTransaction struct {
+	client  *Implementation
+	items   []types.TransactGetItem
+	entries []transactionEntry
+	targets []interface{}
+	err     error
+}
+
+// ReadTransaction starts a new read transaction on the client tables.
+func (i *Implementation) ReadTransaction() *ReadTransaction {
+	return &ReadTransaction{client: i}
+}
+
+// Get adds a read of the item with the given partition key into bindTo.
+func (t *ReadTransaction) Get(table string, partitionKey interface{}, bindTo interface{}) *ReadTransaction {
+	def, ok := t.table(table)
+	if !ok {
+		return t
+	}
+	key, ok := t.key(def, partitionKey)
+	if !ok {
+		return t
+	}
+	return t.get(def, key, bindTo)
+}
+
+// GetWithSort adds a read of the item with the given partition and sort key into bindTo.
+func (t *ReadTransaction) GetWithSort(table string, partitionKey interface{}, sortKey interface{}, bindTo interface{}) *ReadTransaction {
+	def, ok := t.table(table)
+	if !ok {
+		return t
+	}
+	key, ok := t.key(def, partitionKey, sortKey)
+	if !ok {
+		return t
+	}
+	return t.get(def, key, bindTo)
+}
+
+func (t *ReadTransaction) get(def DynamoTable, key map[string]types.AttributeValue, bindTo interface{}) *ReadTransaction {
+	t.items = append(t.items, types.TransactGetItem{Get: &types.Get{
+		TableName: aws.String(def.TableName),
+		Key:       key,
+	}})
+	t.entries = append(t.entries, transactionEntry{table: def.TableName})
+	t.targets = append(t.targets, bindTo)
+	return t
+}
+
+// key builds the key of an item, recording an error when it does not match the table.
+func (t *ReadTransaction) key(def DynamoTable, partitionKey interface{}, sortKey ...interface{}) (map[string]types.AttributeValue, bool) {
+	key, err := def.primaryKey(partitionKey, sortKey...)
+	if err != nil {
+		t.err = err
+		return nil, false
+	}
+	return key, true
+}
+
+func (t *ReadTransaction) table(table string) (DynamoTable, bool) {
+	if t.err != nil {
+		return DynamoTable{}, false
+	}
+	def, err := t.client.table(table)
+	if err != nil {
+		t.err = err
+		return DynamoTable{}, false
+	}
+	return def, true
+}
+
+// Execute reads every item of the transaction into its bind target. Items that do not
+// exist leave their target untouched and make Execute return ErrNotFound once the
+// found items are bound.
+func (t *ReadTransaction) Execute(ctx context.Context) error {
+	if t.err != nil {
+		return t.err
+	}
+	if len(t.items) == 0 {
+		return nil
+	}
+	if len(t.items) > maxTransactionItems {
+		return fmt.Errorf("dynamo: a transaction supports up to %d items, got %d", maxTransactionItems, len(t.items))
+	}
+
+	log.Printf("[DynamoDB] executing read transaction with %d items", len(t.items))
+	out, err := t.client.client.TransactGetItems(ctx, &dynamodb.TransactGetItemsInput{
+		TransactItems: t.items,
+	})
+	if err != nil {
+		return newTransactionError(err, t.entries)
+	}
+
+	missing := false
+	for idx, response := range out.Responses {
+		def, _ := t.client.table(t.entries[idx].table)
+		if response.Item == nil || def.expired(response.Item, t.client.now()) {
+			missing = true
+			continue
+		}
+		err := attributevalue.UnmarshalMapWithOptions(response.Item, t.targets[idx], func(options *attributevalue.DecoderOptions) {
+			options.TagKey = Tagkey
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if missing {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// newTransactionError decodes the cancellation reasons of a canceled transaction.
+// Other errors are returned unchanged.
+func newTransactionError(err error, entries []transactionEntry) error {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return err
+	}
+	txErr := &TransactionError{err: err}
+	for idx, reason := range canceled.CancellationReasons {
+		r := TransactionReason{
+			Code:    aws.ToString(reason.Code),
+			Message: aws.ToString(reason.Message),
+			Item:    reason.Item,
+		}
+		if idx < len(entries) {
+			r.Table = entries[idx].table
+			r.guarded = entries[idx].guarded
+		}
+		txErr.Reasons = append(txErr.Reasons, r)
+	}
+	return txErr
+}
+
+func (e *TransactionError) Error() string {
+	var failed []string
+	for idx, reason := range e.Reasons {
+		if reason.Code == ReasonNone || reason.Code == "" {
+			continue
+		}
+		failed = append(failed, fmt.Sprintf("item %d (%s): %s", idx, reason.Table, reason.Code))
+	}
+	return "dynamo: transaction canceled: " + strings.Join(failed, ", ")
+}
+
+// Unwrap exposes the SDK error, and ErrConditionFailed or ErrVersionConflict when an
+// item failed its condition.
+func (e *TransactionError) Unwrap() []error {
+	errs := []error{e.err}
+	conditionFailed, versionConflict := false, false
+	for _, reason := range e.Reasons {
+		if reason.Code != ReasonConditionalCheckFailed {
+			continue
+		}
+		conditionFailed = true
+		versionConflict = versionConflict || reason.guarded
+	}
+	if conditionFailed {
+		errs = append(errs, ErrConditionFailed)
+	}
+	if versionConflict {
+		errs = append(errs, ErrVersionConflict)
+	}
+	return errs
+}
+
+// Failed returns the reasons of the items that caused the cancellation.
+func (e *TransactionError) Failed() []TransactionReason {
+	var failed []TransactionReason
+	for _, reason := range e.Reasons {
+		if reason.Code != ReasonNone && reason.Code != "" {
+			failed = append(failed, reason)
+		}
+	}
+	return failed
+}
+
+// UnmarshalItem unmarshals the item returned with a failed condition check into bindTo.
+// It returns ErrNotFound when DynamoDB returned no item.
+func (r TransactionReason) UnmarshalItem(bindTo interface{}) error {
+	if r.Item == nil {
+		return ErrNotFound
+	}
+	return attributevalue.UnmarshalMapWithOptions(r.Item, bindTo, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+}
@@ -0,0 +1,152 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func transactionTables() map[string]DynamoTable {
+	return map[string]DynamoTable{
+		"accounts": {TableName: "accounts", PartitionKeyField: "id", VersionField: "version"},
+		"orders":   {TableName: "orders", PartitionKeyField: "user", SortKeyField: "id"},
+	}
+}
+
+func TestWriteTransaction(t *testing.T) {
+	var input *dynamodb.TransactWriteItemsInput
+	client := &Implementation{
+		DynamoTables: transactionTables(),
+		client: &dynamoClientMock{
+			funcTransactWriteItems: func(ctx context.Context, in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+				input = in
+				return &dynamodb.TransactWriteItemsOutput{}, nil
+			},
+		},
+	}
+
+	a := account{ID: "1", Balance: 10, Version: 4}
+	err := client.WriteTransaction().
+		Save("accounts", &a).
+		UpdateWithSort("orders", "1", "o-1", expression.Set(expression.Name("status"), expression.Value("paid"))).
+		DeleteWithSort("orders", "1", "o-2", IfNotExists()).
+		Check("accounts", "2", expression.AttributeExists(expression.Name("id"))).
+		Commit(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, input.TransactItems, 4)
+
+	put := input.TransactItems[0].Put
+	assert.Equal(t, "accounts", *put.TableName)
+	assert.Equal(t, "#0 = :0", *put.ConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "5"}, put.Item["version"])
+	assert.Equal(t, int64(5), a.Version)
+
+	update := input.TransactItems[1].Update
+	assert.Equal(t, map[string]types.AttributeValue{
+		"user": &types.AttributeValueMemberS{Value: "1"},
+		"id":   &types.AttributeValueMemberS{Value: "o-1"},
+	}, update.Key)
+	assert.Equal(t, "SET #0 = :0\n", *update.UpdateExpression)
+	assert.Nil(t, update.ConditionExpression)
+
+	assert.Equal(t, "attribute_not_exists (#0)", *input.TransactItems[2].Delete.ConditionExpression)
+	assert.Equal(t, "attribute_exists (#0)", *input.TransactItems[3].ConditionCheck.ConditionExpression)
+}
+
+func TestWriteTransactionCanceled(t *testing.T) {
+	client := &Implementation{
+		DynamoTables: transactionTables(),
+		client: &dynamoClientMock{
+			funcTransactWriteItems: func(ctx context.Context, in *dynamodb.TransactWriteItemsInput) (*dynamodb.TransactWriteItemsOutput, error) {
+				return nil, &types.TransactionCanceledException{
+					Message: aws.String("Transaction cancelled"),
+					CancellationReasons: []types.CancellationReason{
+						{Code: aws.String(ReasonNone)},
+						{
+							Code:    aws.String(ReasonConditionalCheckFailed),
+							Message: aws.String("The conditional request failed"),
+							Item:    map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}, "version": &types.AttributeValueMemberN{Value: "7"}},
+						},
+					},
+				}
+			},
+		},
+	}
+
+	a := account{ID: "1", Version: 4}
+	err := client.WriteTransaction().
+		DeleteWithSort("orders", "1", "o-1").
+		Save("accounts", &a).
+		Commit(context.Background())
+
+	var txErr *TransactionError
+	assert.True(t, errors.As(err, &txErr))
+	assert.ErrorIs(t, err, ErrConditionFailed)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, int64(4), a.Version)
+	assert.Equal(t, "dynamo: transaction canceled: item 1 (accounts): ConditionalCheckFailed", err.Error())
+
+	failed := txErr.Failed()
+	assert.Len(t, failed, 1)
+	assert.Equal(t, "accounts", failed[0].Table)
+	var stored account
+	assert.NoError(t, failed[0].UnmarshalItem(&stored))
+	assert.Equal(t, int64(7), stored.Version)
+
+	var canceled *types.TransactionCanceledException
+	assert.True(t, errors.As(err, &canceled))
+}
+
+func TestWriteTransactionValidation(t *testing.T) {
+	client := &Implementation{DynamoTables: transactionTables(), client: &dynamoClientMock{}}
+
+	err := client.WriteTransaction().Delete("unknown", "1").Commit(context.Background())
+	assert.ErrorIs(t, err, ErrUnknownTable)
+	assert.EqualError(t, err, "dynamo: unknown table: unknown")
+
+	var old account
+	err = client.WriteTransaction().Delete("accounts", "1", WithOldValue(&old)).Commit(context.Background())
+	assert.Error(t, err)
+
+	tx := client.WriteTransaction()
+	for i := 0; i <= maxTransactionItems; i++ {
+		tx.Delete("accounts", "1")
+	}
+	assert.Error(t, tx.Commit(context.Background()))
+
+	assert.NoError(t, client.WriteTransaction().Commit(context.Background()))
+}
+
+func TestReadTransaction(t *testing.T) {
+	var input *dynamodb.TransactGetItemsInput
+	client := &Implementation{
+		DynamoTables: transactionTables(),
+		client: &dynamoClientMock{
+			funcTransactGetItems: func(ctx context.Context, in *dynamodb.TransactGetItemsInput) (*dynamodb.TransactGetItemsOutput, error) {
+				input = in
+				return &dynamodb.TransactGetItemsOutput{Responses: []types.ItemResponse{
+					{Item: map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "1"}, "balance": &types.AttributeValueMemberN{Value: "10"}}},
+					{},
+				}}, nil
+			},
+		},
+	}
+
+	var a account
+	o := order{Status: "unchanged"}
+	err := client.ReadTransaction().
+		Get("accounts", "1", &a).
+		GetWithSort("orders", "1", "o-1", &o).
+		Execute(context.Background())
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Len(t, input.TransactItems, 2)
+	assert.Equal(t, "orders", *input.TransactItems[1].Get.TableName)
+	assert.Equal(t, 10, a.Balance)
+	assert.Equal(t, "unchanged", o.Status)
+}
@@ -0,0 +1,72 @@
+package dynamodb
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// WithExpiry sets the expiry time of a saved item, overriding the TTL of the table.
+// The table must define a TTLField.
+func WithExpiry(expiresAt time.Time) WriteOption {
+	return func(o *writeOptions) {
+		o.expiresAt = &expiresAt
+	}
+}
+
+// stampExpiry sets the TTL attribute of an item being saved: to the WithExpiry time
+// when given, otherwise to now plus the table TTL unless the item already has one.
+func (d DynamoTable) stampExpiry(item map[string]types.AttributeValue, ops *writeOptions, now time.Time) {
+	if d.TTLField == "" {
+		return
+	}
+	var expiresAt time.Time
+	switch {
+	case ops.expiresAt != nil:
+		expiresAt = *ops.expiresAt
+	case d.TTL > 0 && expiry(item[d.TTLField]) == 0:
+		expiresAt = now.Add(d.TTL)
+	default:
+		return
+	}
+	item[d.TTLField] = &types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)}
+}
+
+// expired reports whether a read item is past its expiry time and should be hidden.
+// Only tables with FilterExpired hide expired items.
+func (d DynamoTable) expired(item map[string]types.AttributeValue, now time.Time) bool {
+	if d.TTLField == "" || !d.FilterExpired {
+		return false
+	}
+	expiresAt := expiry(item[d.TTLField])
+	return expiresAt > 0 && expiresAt <= now.Unix()
+}
+
+// live returns the items that are not expired.
+func (d DynamoTable) live(items []map[string]types.AttributeValue, now time.Time) []map[string]types.AttributeValue {
+	if d.TTLField == "" || !d.FilterExpired {
+		return items
+	}
+	live := items[:0:0]
+	for _, item := range items {
+		if !d.expired(item, now) {
+			live = append(live, item)
+		}
+	}
+	return live
+}
+
+// expiry returns the epoch second held by a TTL attribute, or 0 when the attribute
+// is missing or not a number, which DynamoDB treats as never expiring.
+func expiry(av types.AttributeValue) int64 {
+	n, ok := av.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0
+	}
+	seconds, err := strconv.ParseFloat(n.Value, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(seconds)
+}
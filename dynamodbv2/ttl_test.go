@@ -0,0 +1,99 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type session struct {
+	ID        string `dynamo:"id" json:"id"`
+	ExpiresAt int64  `dynamo:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// fixedClock returns a clock reading the time stored in current.
+func fixedClock(current *time.Time) func() time.Time {
+	return func() time.Time { return *current }
+}
+
+func sessionsTable(filterExpired bool) DynamoTable {
+	return DynamoTable{TableName: "sessions", PartitionKeyField: "id", TTLField: "expires_at", TTL: time.Hour, FilterExpired: filterExpired}
+}
+
+func TestSaveStampsExpiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var items []map[string]types.AttributeValue
+	client := &Implementation{clock: fixedClock(&now), client: &dynamoClientMock{
+		funcPutItem: func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			items = append(items, input.Item)
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}}
+	WithTable(sessionsTable(false))(client)
+
+	assert.NoError(t, client.Save("sessions", session{ID: "1"}))
+	assert.NoError(t, client.Save("sessions", session{ID: "2", ExpiresAt: 42}))
+	assert.NoError(t, client.Save("sessions", session{ID: "3"}, WithExpiry(now.Add(time.Minute))))
+
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "1700003600"}, items[0]["expires_at"])
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "42"}, items[1]["expires_at"])
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "1700000060"}, items[2]["expires_at"])
+}
+
+func TestFilterExpiredItems(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	expired := map[string]types.AttributeValue{
+		"id":         &types.AttributeValueMemberS{Value: "1"},
+		"expires_at": &types.AttributeValueMemberN{Value: "1699999999"},
+	}
+	live := map[string]types.AttributeValue{
+		"id":         &types.AttributeValueMemberS{Value: "2"},
+		"expires_at": &types.AttributeValueMemberN{Value: "1700000001"},
+	}
+	mock := &dynamoClientMock{
+		funcGetItem: func(ctx context.Context, input *dynamodb.GetItemInput) (*dynamodb.GetItemOutput, error) {
+			return &dynamodb.GetItemOutput{Item: expired}, nil
+		},
+		funcQuery: func(ctx context.Context, input *dynamodb.QueryInput) (*dynamodb.QueryOutput, error) {
+			return &dynamodb.QueryOutput{Items: []map[string]types.AttributeValue{expired, live}, Count: 2, ScannedCount: 2}, nil
+		},
+	}
+
+	client := &Implementation{client: mock, clock: fixedClock(&now)}
+	WithTable(sessionsTable(true))(client)
+	var s session
+	assert.ErrorIs(t, client.GetOne("sessions", "1", &s), ErrNotFound)
+
+	var sessions []session
+	result, err := client.QueryKey("sessions", KeyQuery{PartitionKey: "1"}, &sessions)
+	assert.NoError(t, err)
+	assert.Equal(t, []session{{ID: "2", ExpiresAt: 1700000001}}, sessions)
+	assert.Equal(t, int32(1), result.Count)
+	assert.Equal(t, int32(2), result.ScannedCount)
+
+	client = &Implementation{client: mock, clock: fixedClock(&now)}
+	WithTable(sessionsTable(false))(client)
+	assert.NoError(t, client.GetOne("sessions", "1", &s))
+	assert.Equal(t, "1", s.ID)
+}
+
+func TestDynamoLocalDevelopmentExpiresItems(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	client := NewLocalClient().WithTable(sessionsTable(false)).WithClock(fixedClock(&now))
+
+	assert.NoError(t, client.Save("sessions", session{ID: "1"}))
+	assert.NoError(t, client.Save("sessions", session{ID: "2"}, WithExpiry(now.Add(2*time.Hour))))
+
+	var s session
+	assert.NoError(t, client.GetOne("sessions", "1", &s))
+	assert.Equal(t, int64(1700003600), s.ExpiresAt)
+
+	now = now.Add(time.Hour)
+	assert.Error(t, client.GetOne("sessions", "1", &s))
+	assert.NoError(t, client.GetOne("sessions", "2", &s))
+	assert.Len(t, client.data["sessions"], 1)
+}
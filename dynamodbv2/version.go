@@ -0,0 +1,113 @@
+package dynamodb
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	versionName      = "#version"
+	versionIncrement = ":version_increment"
+)
+
+// versionCondition guards a write of an item that was read at the given version.
+// Version 0 means the item was never saved, so it must not exist yet.
+func versionCondition(field string, version int64) expression.ConditionBuilder {
+	if version == 0 {
+		return expression.AttributeNotExists(expression.Name(field))
+	}
+	return expression.Name(field).Equal(expression.Value(version))
+}
+
+// itemVersion returns the version stored in the version field of a marshalled item,
+// or 0 when the field is not set.
+func itemVersion(item map[string]types.AttributeValue, field string) (int64, error) {
+	av, ok := item[field]
+	if !ok {
+		return 0, nil
+	}
+	if _, isNull := av.(*types.AttributeValueMemberNULL); isNull {
+		return 0, nil
+	}
+	n, ok := av.(*types.AttributeValueMemberN)
+	if !ok {
+		return 0, fmt.Errorf("dynamo: version field %s must be a number", field)
+	}
+	return strconv.ParseInt(n.Value, 10, 64)
+}
+
+// withVersionIncrement adds an action incrementing the version field to an update
+// expression.
+func withVersionIncrement(update *string, names map[string]string, values map[string]types.AttributeValue, field string) (*string, map[string]string, map[string]types.AttributeValue) {
+	names, values = withPlaceholders(names, values,
+		map[string]string{versionName: field},
+		map[string]types.AttributeValue{versionIncrement: &types.AttributeValueMemberN{Value: "1"}})
+	return addUpdateAction(update, "ADD", versionName+" "+versionIncrement), names, values
+}
+
+// withPlaceholders returns copies of the names and values of an expression with the
+// given placeholders added.
+func withPlaceholders(names map[string]string, values map[string]types.AttributeValue, addNames map[string]string, addValues map[string]types.AttributeValue) (map[string]string, map[string]types.AttributeValue) {
+	allNames := make(map[string]string, len(names)+len(addNames))
+	for _, m := range []map[string]string{names, addNames} {
+		for k, v := range m {
+			allNames[k] = v
+		}
+	}
+	allValues := make(map[string]types.AttributeValue, len(values)+len(addValues))
+	for _, m := range []map[string]types.AttributeValue{values, addValues} {
+		for k, v := range m {
+			allValues[k] = v
+		}
+	}
+	return allNames, allValues
+}
+
+// addUpdateAction adds an action to the clause of an update expression starting with
+// keyword, such as SET or ADD, adding the clause when the expression has none.
+func addUpdateAction(update *string, keyword string, action string) *string {
+	var clauses []string
+	added := false
+	if update != nil {
+		for _, clause := range strings.Split(strings.TrimSpace(*update), "\n") {
+			if strings.HasPrefix(clause, keyword+" ") {
+				clause += ", " + action
+				added = true
+			}
+			clauses = append(clauses, clause)
+		}
+	}
+	if !added {
+		clauses = append([]string{keyword + " " + action}, clauses...)
+	}
+	expr := strings.Join(clauses, "\n") + "\n"
+	return &expr
+}
+
+// versionTarget checks that an item of a versioned table is passed by pointer, so
+// that it receives its new version and can be saved again without a conflict.
+func versionTarget(def DynamoTable, values interface{}) error {
+	if def.VersionField == "" {
+		return nil
+	}
+	if v := reflect.ValueOf(values); v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("dynamo: items of versioned table %s must be saved by pointer to receive their new version, got %T", def.TableName, values)
+	}
+	return nil
+}
+
+// setVersion writes the new version back into the saved item, which versionTarget
+// checked is a pointer.
+func setVersion(values interface{}, field string, version int64) error {
+	return attributevalue.UnmarshalMapWithOptions(map[string]types.AttributeValue{
+		field: &types.AttributeValueMemberN{Value: strconv.FormatInt(version, 10)},
+	}, values, func(options *attributevalue.DecoderOptions) {
+		options.TagKey = Tagkey
+	})
+}
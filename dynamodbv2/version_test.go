@@ -0,0 +1,156 @@
+package dynamodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/expression"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type account struct {
+	ID      string `dynamo:"id" json:"id"`
+	Balance int    `dynamo:"balance" json:"balance"`
+	Version int64  `dynamo:"version" json:"version"`
+}
+
+func TestSaveVersioned(t *testing.T) {
+	var inputs []*dynamodb.PutItemInput
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"accounts": {TableName: "accounts", PartitionKeyField: "id", VersionField: "version"}},
+		client: &dynamoClientMock{
+			funcPutItem: func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+				inputs = append(inputs, input)
+				if len(inputs) == 3 {
+					return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+				}
+				return &dynamodb.PutItemOutput{}, nil
+			},
+		},
+	}
+
+	a := account{ID: "1", Balance: 10}
+	assert.NoError(t, client.Save("accounts", &a))
+	assert.Equal(t, "attribute_not_exists (#0)", *inputs[0].ConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "1"}, inputs[0].Item["version"])
+	assert.Equal(t, int64(1), a.Version)
+	assert.Equal(t, "1", a.ID)
+
+	assert.NoError(t, client.Save("accounts", &a))
+	assert.Equal(t, "#0 = :0", *inputs[1].ConditionExpression)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "1"}, inputs[1].ExpressionAttributeValues[":0"])
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "2"}, inputs[1].Item["version"])
+	assert.Equal(t, int64(2), a.Version)
+
+	err := client.Save("accounts", &a)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.ErrorIs(t, err, ErrConditionFailed)
+	assert.Equal(t, int64(2), a.Version)
+}
+
+func TestUpdateVersioned(t *testing.T) {
+	var input *dynamodb.UpdateItemInput
+	client := &Implementation{
+		DynamoTables: map[string]DynamoTable{"accounts": {TableName: "accounts", PartitionKeyField: "id", VersionField: "version"}},
+		client: &dynamoClientMock{
+			funcUpdateItem: func(ctx context.Context, in *dynamodb.UpdateItemInput) (*dynamodb.UpdateItemOutput, error) {
+				input = in
+				return &dynamodb.UpdateItemOutput{}, nil
+			},
+		},
+	}
+
+	update := expression.Set(expression.Name("balance"), expression.Value(5))
+	assert.NoError(t, client.Update("accounts", "1", update, nil, WithVersion(3)))
+	assert.Equal(t, "ADD #version :version_increment\nSET #1 = :1\n", *input.UpdateExpression)
+	assert.Equal(t, "#0 = :0", *input.ConditionExpression)
+	assert.Equal(t, "version", input.ExpressionAttributeNames["#version"])
+
+	// the caller's builder is not modified
+	expr, err := expression.NewBuilder().WithUpdate(update).Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "SET #0 = :0\n", *expr.Update())
+
+	assert.NoError(t, client.Update("accounts", "1", expression.Add(expression.Name("balance"), expression.Value(1)), nil))
+	assert.Equal(t, "ADD #0 :0, #version :version_increment\n", *input.UpdateExpression)
+	assert.Nil(t, input.ConditionExpression)
+}
+
+func TestDynamoLocalDevelopmentVersioning(t *testing.T) {
+	client := NewLocalClient().
+		WithTable(DynamoTable{
+			TableName:         "accounts",
+			PartitionKeyField: "id",
+			VersionField:      "version",
+		})
+
+	a := account{ID: "1", Balance: 10}
+	assert.NoError(t, client.Save("accounts", &a))
+	assert.Equal(t, int64(1), a.Version)
+
+	stale := a
+	a.Balance = 20
+	assert.NoError(t, client.Save("accounts", &a))
+	assert.Equal(t, int64(2), a.Version)
+
+	stale.Balance = 30
+	assert.ErrorIs(t, client.Save("accounts", &stale), ErrVersionConflict)
+
+	var stored account
+	err := client.Update("accounts", "1", expression.Set(expression.Name("balance"), expression.Value(25)), &stored, WithVersion(2))
+	assert.NoError(t, err)
+	assert.Equal(t, account{ID: "1", Balance: 25, Version: 3}, stored)
+
+	err = client.Update("accounts", "1", expression.Set(expression.Name("balance"), expression.Value(40)), nil, WithVersion(2))
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	assert.ErrorIs(t, client.Delete("accounts", "1", WithVersion(1)), ErrVersionConflict)
+	assert.NoError(t, client.Delete("accounts", "1", WithVersion(3)))
+}
+
+func TestTableSaveVersionedTwice(t *testing.T) {
+	var stored map[string]types.AttributeValue
+	client := &Implementation{client: &dynamoClientMock{
+		funcPutItem: func(ctx context.Context, input *dynamodb.PutItemInput) (*dynamodb.PutItemOutput, error) {
+			var current map[string]interface{}
+			assert.NoError(t, attributevalue.UnmarshalMap(stored, &current))
+			ok, err := evalCondition(input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, current)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, &types.ConditionalCheckFailedException{Message: aws.String("The conditional request failed")}
+			}
+			stored = input.Item
+			return &dynamodb.PutItemOutput{}, nil
+		},
+	}}
+	accounts, err := NewTable[account](client, DynamoTable{TableName: "accounts", PartitionKeyField: "id", VersionField: "version"})
+	assert.NoError(t, err)
+
+	a := account{ID: "1", Balance: 10}
+	assert.NoError(t, accounts.Save(context.Background(), &a))
+	a.Balance = 20
+	assert.NoError(t, accounts.Save(context.Background(), &a))
+	assert.Equal(t, int64(2), a.Version)
+	assert.Equal(t, &types.AttributeValueMemberN{Value: "2"}, stored["version"])
+
+	stale := account{ID: "1", Balance: 30, Version: 1}
+	assert.ErrorIs(t, accounts.Save(context.Background(), &stale), ErrVersionConflict)
+}
+
+func TestSaveVersionedByValue(t *testing.T) {
+	table := DynamoTable{TableName: "accounts", PartitionKeyField: "id", VersionField: "version"}
+	client := &Implementation{DynamoTables: map[string]DynamoTable{"accounts": table}, client: &dynamoClientMock{}}
+
+	err := client.Save("accounts", account{ID: "1"})
+	assert.EqualError(t, err, "dynamo: items of versioned table accounts must be saved by pointer to receive their new version, got dynamodb.account")
+	err = client.WriteTransaction().Save("accounts", account{ID: "1"}).Commit(context.Background())
+	assert.ErrorContains(t, err, "must be saved by pointer")
+	err = NewLocalClient().WithTable(table).Save("accounts", account{ID: "1"})
+	assert.ErrorContains(t, err, "must be saved by pointer")
+}
@@ -11,6 +11,15 @@ type Spec interface {
 	DeleteMessage(msg *string)
 	ReadMessages(execute func(msg types.Message) error)
 	SendMessage(msg interface{}) (*sqs.SendMessageOutput, error)
+	SendMessageWithOptions(msg interface{}, ops Options) (*sqs.SendMessageOutput, error)
+}
+
+// Options are the message attributes, and the group and deduplication ids required by
+// FIFO queues, of a message sent with SendMessageWithOptions.
+type Options struct {
+	MessageAttributes map[string]any
+	MessageGroupID    *string
+	DeduplicationID   *string
 }
 
 type Config struct {
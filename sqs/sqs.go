@@ -3,6 +3,7 @@ package sqs
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"log"
 
@@ -161,3 +162,62 @@ func (s SqsConfig) SendMessage(msg interface{}) (*sqs.SendMessageOutput, error)
 	return rst, nil
 
 }
+
+// SendMessageWithOptions func to send a msg with message attributes and, for FIFO
+// queues, its group and deduplication ids.
+// Inputs: msg, marshalled to JSON as the message body, and Options
+// Output: *sqs.SendMessageOutput
+func (s SqsConfig) SendMessageWithOptions(msg interface{}, ops Options) (*sqs.SendMessageOutput, error) {
+	msgByte, err := json.Marshal(msg)
+	if err != nil {
+		log.Println("ERROR:", err)
+		return nil, err
+	}
+	attributes := makeMapAttributes(ops.MessageAttributes)
+	if s.local {
+		localMessages = append(localMessages, types.Message{
+			Body:              aws.String(string(msgByte)),
+			MessageAttributes: attributes,
+		})
+		return &sqs.SendMessageOutput{}, nil
+	}
+
+	resutlsqsURL, err := getQueueURL(s)
+	if err != nil {
+		log.Println("ERROR:", err)
+		return nil, err
+	}
+
+	rst, err := s.Client.SendMessage(context.Background(), &sqs.SendMessageInput{
+		QueueUrl:               resutlsqsURL.QueueUrl,
+		MessageBody:            aws.String(string(msgByte)),
+		MessageAttributes:      attributes,
+		MessageGroupId:         ops.MessageGroupID,
+		MessageDeduplicationId: ops.DeduplicationID,
+	})
+	if err != nil {
+		log.Println("ERROR:", err)
+		return nil, err
+	}
+	return rst, nil
+}
+
+// makeMapAttributes converts message attributes to SQS ones: strings are sent as
+// String, byte slices as Binary and other values as their String representation.
+func makeMapAttributes(ma map[string]any) map[string]types.MessageAttributeValue {
+	if len(ma) == 0 {
+		return nil
+	}
+	attributes := make(map[string]types.MessageAttributeValue, len(ma))
+	for key, value := range ma {
+		switch v := value.(type) {
+		case string:
+			attributes[key] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(v)}
+		case []byte:
+			attributes[key] = types.MessageAttributeValue{DataType: aws.String("Binary"), BinaryValue: v}
+		default:
+			attributes[key] = types.MessageAttributeValue{DataType: aws.String("String"), StringValue: aws.String(fmt.Sprintf("%v", v))}
+		}
+	}
+	return attributes
+}